@@ -0,0 +1,153 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"example.com/website/v1alpha1/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &Static{}
+
+// sourceFields are the fields of the spec describing the source, which are grouped in the source of v1beta1
+var sourceFields = []string{"source", "sha256", "git", "configMaps", "secrets", "credentialsSecretRef", "s3"}
+
+// sourceTypes are the types of v1beta1 sources for the schemes of the URLs of the sources
+var sourceTypes = map[string]v1beta1.SourceType{
+	"gs":    v1beta1.SourceTypeGCS,
+	"s3":    v1beta1.SourceTypeS3,
+	"oci":   v1beta1.SourceTypeOCI,
+	"http":  v1beta1.SourceTypeHTTP,
+	"https": v1beta1.SourceTypeHTTP,
+}
+
+// ConvertTo converts this Static to the hub version (v1beta1).
+// A single source is converted, the Statics setting several sources being rejected by the webhook
+func (src *Static) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Static)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1beta1.StaticSpec{}
+	dst.Status = v1beta1.StaticStatus{}
+
+	// The fields other than the source have the same schema in both versions
+	if err := convertFields(src.Spec, &dst.Spec, sourceFields...); err != nil {
+		return err
+	}
+	if err := convertFields(src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	source := v1beta1.Source{
+		Sha256:      src.Spec.Sha256,
+		Credentials: src.Spec.CredentialsSecretRef,
+		ConfigMaps:  src.Spec.ConfigMaps,
+		Secrets:     src.Spec.Secrets,
+	}
+	if src.Spec.S3 != nil {
+		source.S3 = &v1beta1.S3Source{
+			Endpoint:  src.Spec.S3.Endpoint,
+			Region:    src.Spec.S3.Region,
+			PathStyle: src.Spec.S3.PathStyle,
+		}
+	}
+	switch {
+	case src.Spec.Git != nil:
+		source.Type = v1beta1.SourceTypeGit
+		source.URL = src.Spec.Git.Repository
+		source.Ref = src.Spec.Git.Ref
+		source.Directory = src.Spec.Git.Directory
+	case src.Spec.Source == "" && (len(src.Spec.ConfigMaps) > 0 || len(src.Spec.Secrets) > 0):
+		source.Type = v1beta1.SourceTypeInline
+	case src.Spec.Source != "":
+		source.URL = src.Spec.Source
+		// The scheme is kept as type when it is not supported, for the webhook to reject it
+		scheme := strings.SplitN(src.Spec.Source, "://", 2)[0]
+		source.Type = v1beta1.SourceType(scheme)
+		if sourceType, found := sourceTypes[scheme]; found {
+			source.Type = sourceType
+		}
+	}
+	dst.Spec.Source = source
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version
+func (dst *Static) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Static)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = StaticSpec{}
+	dst.Status = StaticStatus{}
+
+	if err := convertFields(src.Spec, &dst.Spec, "source"); err != nil {
+		return err
+	}
+	if err := convertFields(src.Status, &dst.Status); err != nil {
+		return err
+	}
+
+	source := src.Spec.Source
+	switch source.Type {
+	case v1beta1.SourceTypeGit:
+		dst.Spec.Git = &GitSource{
+			Repository: source.URL,
+			Ref:        source.Ref,
+			Directory:  source.Directory,
+		}
+	case v1beta1.SourceTypeInline:
+	default:
+		dst.Spec.Source = source.URL
+	}
+	dst.Spec.Sha256 = source.Sha256
+	dst.Spec.CredentialsSecretRef = source.Credentials
+	dst.Spec.ConfigMaps = source.ConfigMaps
+	dst.Spec.Secrets = source.Secrets
+	if source.S3 != nil {
+		dst.Spec.S3 = &S3Source{
+			Endpoint:  source.S3.Endpoint,
+			Region:    source.S3.Region,
+			PathStyle: source.S3.PathStyle,
+		}
+	}
+	return nil
+}
+
+// convertFields copies the fields of src to the fields with the same JSON name in dst, except the omitted ones
+func convertFields(src interface{}, dst interface{}, omitted ...string) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, field := range omitted {
+		delete(fields, field)
+	}
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("converting %T: %v", src, err)
+	}
+	return nil
+}
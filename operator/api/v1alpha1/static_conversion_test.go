@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	"example.com/website/v1alpha1/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   StaticSpec
+		source v1beta1.Source
+	}{
+		{
+			name:   "bucket",
+			spec:   StaticSpec{Source: "gs://my-bucket/docs", CredentialsSecretRef: &corev1.LocalObjectReference{Name: "gcs-key"}},
+			source: v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs", Credentials: &corev1.LocalObjectReference{Name: "gcs-key"}},
+		},
+		{
+			name:   "s3",
+			spec:   StaticSpec{Source: "s3://my-bucket/docs", S3: &S3Source{Endpoint: "http://minio.minio:9000", PathStyle: true}},
+			source: v1beta1.Source{Type: v1beta1.SourceTypeS3, URL: "s3://my-bucket/docs", S3: &v1beta1.S3Source{Endpoint: "http://minio.minio:9000", PathStyle: true}},
+		},
+		{
+			name:   "archive",
+			spec:   StaticSpec{Source: "https://example.com/site.tar.gz", Sha256: "abcd"},
+			source: v1beta1.Source{Type: v1beta1.SourceTypeHTTP, URL: "https://example.com/site.tar.gz", Sha256: "abcd"},
+		},
+		{
+			name:   "git",
+			spec:   StaticSpec{Git: &GitSource{Repository: "https://github.com/example/docs.git", Ref: "main", Directory: "public"}},
+			source: v1beta1.Source{Type: v1beta1.SourceTypeGit, URL: "https://github.com/example/docs.git", Ref: "main", Directory: "public"},
+		},
+		{
+			name:   "inline",
+			spec:   StaticSpec{ConfigMaps: []corev1.LocalObjectReference{{Name: "my-site"}}},
+			source: v1beta1.Source{Type: v1beta1.SourceTypeInline, ConfigMaps: []corev1.LocalObjectReference{{Name: "my-site"}}},
+		},
+		{
+			name:   "unsupported scheme",
+			spec:   StaticSpec{Source: "ftp://example.com/docs"},
+			source: v1beta1.Source{Type: "ftp", URL: "ftp://example.com/docs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := &Static{Spec: tt.spec}
+			static.Spec.DiskSize = resource.MustParse("20Mi")
+			hub := &v1beta1.Static{}
+			if err := static.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			if !reflect.DeepEqual(hub.Spec.Source, tt.source) {
+				t.Errorf("ConvertTo() source = %+v, want %+v", hub.Spec.Source, tt.source)
+			}
+
+			back := &Static{}
+			if err := back.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			if !reflect.DeepEqual(back.Spec, static.Spec) {
+				t.Errorf("ConvertFrom() spec = %+v, want %+v", back.Spec, static.Spec)
+			}
+		})
+	}
+}
+
+func TestConvertOtherFields(t *testing.T) {
+	maxAge := metav1.Duration{Duration: 3600000000000}
+	static := &Static{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-static", Namespace: "default", Generation: 2},
+		Spec: StaticSpec{
+			DiskSize:    resource.MustParse("1Gi"),
+			Source:      "oci://ghcr.io/example/docs:latest",
+			MinReplicas: 2,
+			MaxReplicas: 5,
+			SPA:         true,
+			Headers:     []ResponseHeaders{{Path: "/", Values: map[string]string{"X-Frame-Options": "DENY"}}},
+			Caching:     &Caching{Rules: []CacheRule{{Pattern: "*.js", MaxAge: &maxAge, Immutable: true}}},
+		},
+		Status: StaticStatus{
+			Replicas:           2,
+			URL:                "http://10.0.0.1",
+			ObservedGeneration: 2,
+			Conditions:         []StaticCondition{{Type: Ready, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	hub := &v1beta1.Static{}
+	if err := static.ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+	if hub.Name != "my-static" || hub.Spec.MaxReplicas != 5 || !hub.Spec.SPA || hub.Spec.Caching.Rules[0].MaxAge.Duration != maxAge.Duration || hub.Status.URL != "http://10.0.0.1" {
+		t.Errorf("ConvertTo() = %+v", hub)
+	}
+
+	back := &Static{}
+	if err := back.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+	if !reflect.DeepEqual(back, static) {
+		t.Errorf("ConvertFrom() = %+v, want %+v", back, static)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"example.com/website/v1alpha1/api/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var staticlog = logf.Log.WithName("static-resource")
+
+// SetupWebhookWithManager registers the webhooks of v1alpha1, which default and validate the Statics
+// as the webhooks of v1beta1 do, by converting them to v1beta1
+func (r *Static) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-website-example-com-v1alpha1-static,mutating=true,failurePolicy=fail,groups=website.example.com,resources=statics,verbs=create;update,versions=v1alpha1,name=mstatic.kb.io
+
+var _ webhook.Defaulter = &Static{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *Static) Default() {
+	if len(r.validateSources()) > 0 {
+		// The conversion would keep only one of the sources, the Static is rejected by the validation instead
+		return
+	}
+	hub := &v1beta1.Static{}
+	if err := r.ConvertTo(hub); err != nil {
+		staticlog.Error(err, "unable to convert", "name", r.Name)
+		return
+	}
+	hub.Default()
+	if err := r.ConvertFrom(hub); err != nil {
+		staticlog.Error(err, "unable to convert", "name", r.Name)
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-website-example-com-v1alpha1-static,mutating=false,failurePolicy=fail,groups=website.example.com,resources=statics,versions=v1alpha1,name=vstatic.kb.io
+
+var _ webhook.Validator = &Static{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateCreate() error {
+	if allErrs := r.validateSources(); len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("Static").GroupKind(), r.Name, allErrs)
+	}
+	hub := &v1beta1.Static{}
+	if err := r.ConvertTo(hub); err != nil {
+		return err
+	}
+	return hub.ValidateCreate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateUpdate(old runtime.Object) error {
+	if allErrs := r.validateSources(); len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("Static").GroupKind(), r.Name, allErrs)
+	}
+	hub, oldHub := &v1beta1.Static{}, &v1beta1.Static{}
+	if err := r.ConvertTo(hub); err != nil {
+		return err
+	}
+	if err := old.(*Static).ConvertTo(oldHub); err != nil {
+		return err
+	}
+	return hub.ValidateUpdate(oldHub)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateDelete() error {
+	return nil
+}
+
+// validateSources checks that a single source is set among source, git and the inline ConfigMaps and Secrets,
+// the source of v1beta1 having a single type
+func (r *Static) validateSources() field.ErrorList {
+	spec := field.NewPath("spec")
+	var sources []*field.Path
+	if r.Spec.Source != "" {
+		sources = append(sources, spec.Child("source"))
+	}
+	if r.Spec.Git != nil {
+		sources = append(sources, spec.Child("git"))
+	}
+	if len(r.Spec.ConfigMaps) > 0 || len(r.Spec.Secrets) > 0 {
+		sources = append(sources, spec.Child("configMaps"))
+	}
+	var allErrs field.ErrorList
+	for i := 1; i < len(sources); i++ {
+		allErrs = append(allErrs, field.Forbidden(sources[i], fmt.Sprintf("cannot be set with %s", sources[0])))
+	}
+	return allErrs
+}
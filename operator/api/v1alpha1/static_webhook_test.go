@@ -0,0 +1,272 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validStatic returns a Static accepted by the webhook
+func validStatic() *Static {
+	return &Static{
+		Spec: StaticSpec{
+			DiskSize:    resource.MustParse("20Mi"),
+			Source:      "gs://my-bucket/docs",
+			MinReplicas: 1,
+			MaxReplicas: 2,
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(static *Static)
+		invalid bool
+	}{
+		{
+			name:   "valid static",
+			mutate: func(static *Static) {},
+		},
+		{
+			name: "inline static without source",
+			mutate: func(static *Static) {
+				static.Spec.Source = ""
+				static.Spec.ConfigMaps = []corev1.LocalObjectReference{{Name: "my-site"}}
+			},
+		},
+		{
+			name: "git and source",
+			mutate: func(static *Static) {
+				static.Spec.Git = &GitSource{Repository: "https://example.com/my-site.git"}
+			},
+			invalid: true,
+		},
+		{
+			name: "source and inline ConfigMaps",
+			mutate: func(static *Static) {
+				static.Spec.ConfigMaps = []corev1.LocalObjectReference{{Name: "my-site"}}
+			},
+			invalid: true,
+		},
+		{
+			name: "minReplicas greater than maxReplicas",
+			mutate: func(static *Static) {
+				static.Spec.MinReplicas = 3
+			},
+			invalid: true,
+		},
+		{
+			name: "zero minReplicas",
+			mutate: func(static *Static) {
+				static.Spec.MinReplicas = 0
+			},
+			invalid: true,
+		},
+		{
+			name: "negative maxReplicas",
+			mutate: func(static *Static) {
+				static.Spec.MaxReplicas = -1
+			},
+			invalid: true,
+		},
+		{
+			name: "sync interval of one hour",
+			mutate: func(static *Static) {
+				static.Spec.SyncInterval = &metav1.Duration{Duration: time.Hour}
+			},
+		},
+		{
+			name: "sync interval too short",
+			mutate: func(static *Static) {
+				static.Spec.SyncInterval = &metav1.Duration{Duration: 500 * time.Millisecond}
+			},
+			invalid: true,
+		},
+		{
+			name: "poll interval of one hour",
+			mutate: func(static *Static) {
+				static.Spec.PollInterval = &metav1.Duration{Duration: time.Hour}
+			},
+		},
+		{
+			name: "poll interval too short",
+			mutate: func(static *Static) {
+				static.Spec.PollInterval = &metav1.Duration{Duration: time.Second}
+			},
+			invalid: true,
+		},
+		{
+			name: "zero diskSize",
+			mutate: func(static *Static) {
+				static.Spec.DiskSize = resource.MustParse("0")
+			},
+			invalid: true,
+		},
+		{
+			name: "unsupported scheme",
+			mutate: func(static *Static) {
+				static.Spec.Source = "ftp://example.com/docs"
+			},
+			invalid: true,
+		},
+		{
+			name: "malformed source",
+			mutate: func(static *Static) {
+				static.Spec.Source = "gs://my bucket%/docs"
+			},
+			invalid: true,
+		},
+		{
+			name: "source without bucket",
+			mutate: func(static *Static) {
+				static.Spec.Source = "s3:///docs"
+			},
+			invalid: true,
+		},
+		{
+			name: "missing source",
+			mutate: func(static *Static) {
+				static.Spec.Source = ""
+			},
+			invalid: true,
+		},
+		{
+			name: "valid headers",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"Cache-Control": "no-cache", "Access-Control-Allow-Origin": "*"}}}
+			},
+		},
+		{
+			name: "invalid header name",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"X-Frame Options": "DENY"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "header value with a new line",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"X-Frame-Options": "DENY\r\nSet-Cookie: a=b"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "header value with a variable",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"Cache-Control": "max-age=$foo"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "valid regular expressions",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: `\.(js|css)$`, Regex: true, Values: map[string]string{"Access-Control-Allow-Origin": "*"}}}
+				static.Spec.Redirects = []Redirect{{From: "^/blog/(.*)$", To: "/posts/$1", Regex: true}}
+			},
+		},
+		{
+			name: "invalid header path regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/(assets", Regex: true, Values: map[string]string{"Access-Control-Allow-Origin": "*"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "https with the secret of a certificate",
+			mutate: func(static *Static) {
+				static.Spec.HTTPS = &HTTPS{SecretName: "docs-example-com-tls"}
+			},
+		},
+		{
+			name: "https with a certificate issued by cert-manager",
+			mutate: func(static *Static) {
+				static.Spec.Ingress = &Ingress{Hosts: []string{"docs.example.com"}}
+				static.Spec.TLS = &TLS{}
+				static.Spec.HTTPS = &HTTPS{}
+			},
+		},
+		{
+			name: "https without certificate",
+			mutate: func(static *Static) {
+				static.Spec.HTTPS = &HTTPS{RedirectHTTP: true}
+			},
+			invalid: true,
+		},
+		{
+			name: "invalid redirect regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Redirects = []Redirect{{From: "^/blog/[a-", To: "/posts/", Regex: true}}
+			},
+			invalid: true,
+		},
+		{
+			name: "redirect path not interpreted as a regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Redirects = []Redirect{{From: "/blog/[a-", To: "/posts/"}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := validStatic()
+			tt.mutate(static)
+			if err := static.ValidateCreate(); (err != nil) != tt.invalid {
+				t.Errorf("ValidateCreate() error = %v, invalid %v", err, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        StaticSpec
+		minReplicas int32
+		maxReplicas int32
+		diskSize    string
+	}{
+		{
+			name:        "empty spec",
+			spec:        StaticSpec{},
+			minReplicas: 1,
+			maxReplicas: 4,
+			diskSize:    "20Mi",
+		},
+		{
+			name:        "minReplicas greater than the default maxReplicas",
+			spec:        StaticSpec{MinReplicas: 6},
+			minReplicas: 6,
+			maxReplicas: 6,
+			diskSize:    "20Mi",
+		},
+		{
+			name:        "values set",
+			spec:        StaticSpec{MinReplicas: 2, MaxReplicas: 3, DiskSize: resource.MustParse("1Gi")},
+			minReplicas: 2,
+			maxReplicas: 3,
+			diskSize:    "1Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := &Static{Spec: tt.spec}
+			static.Default()
+			if static.Spec.MinReplicas != tt.minReplicas || static.Spec.MaxReplicas != tt.maxReplicas || static.Spec.DiskSize.String() != tt.diskSize {
+				t.Errorf("Default() = %d, %d, %s, want %d, %d, %s", static.Spec.MinReplicas, static.Spec.MaxReplicas, static.Spec.DiskSize.String(), tt.minReplicas, tt.maxReplicas, tt.diskSize)
+			}
+		})
+	}
+}
+
+func TestDefaultMixedSources(t *testing.T) {
+	static := validStatic()
+	static.Spec.Git = &GitSource{Repository: "https://example.com/my-site.git"}
+	static.Default()
+	if static.Spec.Source != "gs://my-bucket/docs" || static.Spec.Git == nil {
+		t.Errorf("Default() = %q, %v, want both sources kept for the validation", static.Spec.Source, static.Spec.Git)
+	}
+}
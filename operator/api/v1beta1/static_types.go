@@ -0,0 +1,603 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// StaticSpec defines the desired state of Static
+type StaticSpec struct {
+	// DiskSize indicates the amount of disk space to reserve to store assets for each instance.
+	// Defaults to the disk size in the configuration of the operator
+	// +optional
+	DiskSize resource.Quantity `json:"diskSize,omitempty"`
+
+	// Source indicates where the assets to serve are copied from
+	Source Source `json:"source"`
+
+	// SyncInterval enables a sidecar copying again the assets from the source at this interval, so that the running
+	// instances serve the updated assets without restarting; the served directory is switched atomically after each copy.
+	// It is ignored for inline sources and shared volumes, and must be at least 1m
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// PollInterval enables a check of the content of the source at this interval: when the content has changed
+	// (object generations or ETags, commit, image digest or archive ETag), the instances are restarted to serve it.
+	// It is ignored for inline sources, whose changes are always served. It must be at least 1m
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// ServiceAccount configures the ServiceAccount of the instances, to give them their own access to the source
+	// with GKE Workload Identity or IAM Roles for Service Accounts
+	// +optional
+	ServiceAccount *ServiceAccount `json:"serviceAccount,omitempty"`
+
+	// SharedVolume mounts a volume shared by all the instances, in place of a copy of the assets for each instance;
+	// DiskSize is then the size requested for the volume
+	// +optional
+	SharedVolume *SharedVolume `json:"sharedVolume,omitempty"`
+
+	// Service configures the Service exposing the instances
+	// +optional
+	Service *Service `json:"service,omitempty"`
+
+	// Ingress exposes the instances through an Ingress, in place of a load balancer for each Static
+	// +optional
+	Ingress *Ingress `json:"ingress,omitempty"`
+
+	// Gateway exposes the instances through a Gateway API HTTPRoute attached to a Gateway
+	// +optional
+	Gateway *Gateway `json:"gateway,omitempty"`
+
+	// TLS serves the hostnames of the Ingress or the Gateway over HTTPS, with a certificate issued by cert-manager.
+	// With a Gateway, the listener must reference the Secret reported in the status as certificateSecretName
+	// +optional
+	TLS *TLS `json:"tls,omitempty"`
+
+	// Serving configures how nginx serves the assets
+	// +optional
+	Serving *Serving `json:"serving,omitempty"`
+
+	// SPA serves a single-page application: the paths without a file extension which are not found are routes
+	// of the application, the index file is served for them. The paths with a file extension are assets,
+	// and are not found when missing. TryFiles is ignored
+	// +optional
+	SPA bool `json:"spa,omitempty"`
+
+	// Redirects are the redirects served before the assets
+	// +optional
+	Redirects []Redirect `json:"redirects,omitempty"`
+
+	// ImportRedirects imports the redirects of the `_redirects` file at the root of the assets, in the format of Netlify,
+	// when the pods start. Splats are supported, rewrites, placeholders and conditions are ignored.
+	// The redirects of the spec take precedence
+	// +optional
+	ImportRedirects bool `json:"importRedirects,omitempty"`
+
+	// Headers are the headers added to the responses for the paths matching patterns.
+	// When several patterns matching a path set the same header, the first one is applied.
+	// The headers are sent with the successful and redirect responses, the security headers with the errors too
+	// +optional
+	Headers []ResponseHeaders `json:"headers,omitempty"`
+
+	// SecurityHeaders adds a preset of security headers to all the responses, unless Headers set them.
+	// `strict` sets HSTS, a same-origin Content-Security-Policy, X-Frame-Options, X-Content-Type-Options,
+	// Referrer-Policy and Permissions-Policy
+	// +kubebuilder:validation:Enum=strict
+	// +optional
+	SecurityHeaders string `json:"securityHeaders,omitempty"`
+
+	// Caching configures the caching of the responses by the browsers and the CDNs
+	// +optional
+	Caching *Caching `json:"caching,omitempty"`
+
+	// HTTPS serves the instances over HTTPS directly from nginx, for clusters without an Ingress controller
+	// +optional
+	HTTPS *HTTPS `json:"https,omitempty"`
+
+	// MinReplicas indicates the minimal number of instances to deploy, 1 by default.
+	// The default is set by the webhook, the CRD being served in apiextensions.k8s.io/v1beta1
+	// +optional
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas indicates the maximal number of instances to deploy.
+	// Defaults to the maximal number of replicas in the configuration of the operator, and at least MinReplicas
+	// +optional
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+}
+
+// Service describes the Service exposing the instances of a Static
+type Service struct {
+	// Type is the type of the Service, LoadBalancer by default, or ClusterIP when exposed through an Ingress or a Gateway
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Port is the port exposed by the Service, 80 by default
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// LoadBalancerSourceRanges restricts the clients of a LoadBalancer Service to these CIDRs
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// ExternalTrafficPolicy indicates whether the external traffic is routed to node-local (Local)
+	// or cluster-wide (Cluster) instances, for NodePort and LoadBalancer Services
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
+	// Annotations are set on the Service, e.g. `networking.gke.io/load-balancer-type: Internal`
+	// for an internal load balancer
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Ingress describes the Ingress exposing the instances of a Static
+type Ingress struct {
+	// Hosts are the hostnames served by the Ingress, all the hostnames are served when empty
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Paths are the paths served by the Ingress, `/` when empty
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+
+	// IngressClassName is the class of the Ingress controller serving the Ingress,
+	// set as `kubernetes.io/ingress.class` annotation
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Annotations are set on the Ingress, to configure the Ingress controller
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Gateway describes the HTTPRoute attaching the instances of a Static to a Gateway
+type Gateway struct {
+	// Name is the name of the parent Gateway
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the parent Gateway, the namespace of the Static when empty
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the listener of the Gateway, all the listeners are used when empty
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+
+	// Hostnames are the hostnames served by the HTTPRoute, the hostnames of the listeners are served when empty
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// Paths are the path prefixes served by the HTTPRoute, `/` when empty
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+}
+
+// TLS describes the certificate requested to cert-manager for the hostnames of a Static
+type TLS struct {
+	// IssuerName is the name of the cert-manager issuer of the certificate, the issuer configured for the operator when empty
+	// +optional
+	IssuerName string `json:"issuerName,omitempty"`
+
+	// IssuerKind is the kind of the cert-manager issuer, the kind configured for the operator when empty
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	IssuerKind string `json:"issuerKind,omitempty"`
+}
+
+// Serving describes how the assets of a Static are served by nginx
+type Serving struct {
+	// IndexFiles are the files served for a directory, `index.html` and `index.htm` by default
+	// +optional
+	IndexFiles []string `json:"indexFiles,omitempty"`
+
+	// TryFiles are the files tried in order for a request, in the syntax of the nginx `try_files` directive;
+	// the last one is the fallback, e.g. `$uri`, `$uri.html`, `$uri/` and `=404` to serve `/about` from `about.html`
+	// +optional
+	TryFiles []string `json:"tryFiles,omitempty"`
+
+	// Gzip compresses the responses
+	// +optional
+	Gzip *Gzip `json:"gzip,omitempty"`
+
+	// ErrorPages are the pages served in place of the default error pages of nginx
+	// +optional
+	ErrorPages []ErrorPage `json:"errorPages,omitempty"`
+
+	// MimeTypes associates file extensions (without dot) to the MIME types to serve them with,
+	// in addition to or in place of the types known by nginx
+	// +optional
+	MimeTypes map[string]string `json:"mimeTypes,omitempty"`
+}
+
+// Gzip describes the compression of the responses
+type Gzip struct {
+	// Types are the MIME types of the compressed responses, in addition to `text/html`.
+	// CSS, JavaScript, JSON, SVG, XML and plain text are compressed when empty
+	// +optional
+	Types []string `json:"types,omitempty"`
+
+	// MinLength is the minimal length in bytes of the compressed responses, 1024 by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinLength *int32 `json:"minLength,omitempty"`
+}
+
+// ErrorPage describes a page served for error status codes
+type ErrorPage struct {
+	// Codes are the HTTP status codes for which the page is served
+	// +kubebuilder:validation:MinItems=1
+	Codes []int32 `json:"codes"`
+
+	// Page is the path of the page in the assets, e.g. `/404.html`
+	Page string `json:"page"`
+}
+
+// Redirect describes a redirect served by the instances of a Static
+type Redirect struct {
+	// From is the path redirected, or a regular expression matching the paths redirected when Regex is set
+	// +kubebuilder:validation:MinLength=1
+	From string `json:"from"`
+
+	// To is the URL or the path of the redirection. With Regex, it can reference the groups captured in From, e.g. `$1`
+	// +kubebuilder:validation:MinLength=1
+	To string `json:"to"`
+
+	// Status is the status code of the redirection, 301 by default
+	// +kubebuilder:validation:Enum=301;302;307;308
+	// +optional
+	Status int32 `json:"status,omitempty"`
+
+	// Regex interprets From as a regular expression
+	// +optional
+	Regex bool `json:"regex,omitempty"`
+}
+
+// ResponseHeaders describes headers added to the responses for the paths matching a pattern
+type ResponseHeaders struct {
+	// Path is the prefix of the paths, or a regular expression matching the paths when Regex is set
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Regex interprets Path as a regular expression
+	// +optional
+	Regex bool `json:"regex,omitempty"`
+
+	// Values associates the names of the headers to their values, e.g. `Access-Control-Allow-Origin: "*"`.
+	// The values cannot contain `$`
+	Values map[string]string `json:"values"`
+}
+
+// Caching describes the caching of the responses of a Static
+type Caching struct {
+	// Rules set the Cache-Control header of the responses for the paths matching patterns.
+	// When several patterns match a path, the first one is applied. Cache-Control set in Headers takes precedence
+	// +optional
+	Rules []CacheRule `json:"rules,omitempty"`
+
+	// ETag sends the ETag header and handles the If-None-Match requests, true by default
+	// +optional
+	ETag *bool `json:"etag,omitempty"`
+
+	// LastModified sends the Last-Modified header and handles the If-Modified-Since requests, true by default
+	// +optional
+	LastModified *bool `json:"lastModified,omitempty"`
+}
+
+// CacheRule describes the caching of the responses for the paths matching a pattern
+type CacheRule struct {
+	// Pattern is a glob matching the paths: `*` matches any characters but `/`, `**` any characters, `?` one character.
+	// A pattern without `/` matches the last segment of the paths, e.g. `*.html`; otherwise the whole path, e.g. `/assets/**`
+	// +kubebuilder:validation:MinLength=1
+	Pattern string `json:"pattern"`
+
+	// MaxAge is how long the responses can be cached, e.g. `8760h` for the assets with a hash in their name
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// Immutable indicates that the responses never change, and do not need to be revalidated while they are fresh
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+
+	// NoCache forces the revalidation of the responses before they are used, e.g. for the HTML pages. MaxAge is ignored
+	// +optional
+	NoCache bool `json:"noCache,omitempty"`
+}
+
+// HTTPS describes how the instances of a Static serve HTTPS
+type HTTPS struct {
+	// SecretName is the name of a Secret of type `kubernetes.io/tls` containing the certificate and its key.
+	// The Secret of the certificate issued by cert-manager is used when empty, TLS being then required
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Port is the HTTPS port exposed by the Service, 443 by default
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// RedirectHTTP redirects the HTTP requests to HTTPS
+	// +optional
+	RedirectHTTP bool `json:"redirectHTTP,omitempty"`
+}
+
+// ServiceAccount describes the ServiceAccount used by the instances of a Static
+type ServiceAccount struct {
+	// Name is the name of an existing ServiceAccount. When empty, the operator creates a ServiceAccount for the Static
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Annotations are set on the ServiceAccount created by the operator, e.g. `iam.gke.io/gcp-service-account`
+	// for GKE Workload Identity or `eks.amazonaws.com/role-arn` for IAM Roles for Service Accounts
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SharedVolume describes a PersistentVolumeClaim containing the assets, mounted read-only by all the instances
+type SharedVolume struct {
+	// ClaimName is the name of an existing PersistentVolumeClaim, already populated with the assets.
+	// When empty, the operator creates a PersistentVolumeClaim and populates it from the source with a Job;
+	// when the source changes, a new PersistentVolumeClaim is populated and served once ready
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// StorageClassName is the storage class of the PersistentVolumeClaim created by the operator
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// S3Source describes how to access an S3-compatible storage
+type S3Source struct {
+	// Endpoint is the URL of the S3-compatible API (e.g. `http://minio.minio:9000`), AWS S3 is used when empty
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// PathStyle forces path-style addressing of the bucket (`endpoint/bucket-name/path`), generally required by MinIO
+	// +optional
+	PathStyle bool `json:"pathStyle,omitempty"`
+}
+
+// SourceType is the type of a source of assets
+type SourceType string
+
+const (
+	// SourceTypeGCS copies the assets from a Google Cloud Storage bucket, with a URL in the form `gs://bucket-name/path`
+	SourceTypeGCS SourceType = "gcs"
+
+	// SourceTypeS3 copies the assets from an S3-compatible bucket, with a URL in the form `s3://bucket-name/path`
+	SourceTypeS3 SourceType = "s3"
+
+	// SourceTypeGit clones the assets from a Git repository, whose URL is the URL of the repository
+	SourceTypeGit SourceType = "git"
+
+	// SourceTypeOCI copies the assets from the root of an image, with a URL in the form `oci://registry/image:tag`
+	// or `oci://registry/image@digest`
+	SourceTypeOCI SourceType = "oci"
+
+	// SourceTypeHTTP downloads an archive (`.tar.gz`, `.tgz`, `.tar` or `.zip`) containing the assets, with a URL
+	// in the form `https://host/path/site.tar.gz`
+	SourceTypeHTTP SourceType = "http"
+
+	// SourceTypeInline serves the keys of ConfigMaps and Secrets as files, for tiny sites
+	SourceTypeInline SourceType = "inline"
+)
+
+// Source describes where the assets to serve are copied from
+type Source struct {
+	// Type is the type of the source
+	// +kubebuilder:validation:Enum=gcs;s3;git;oci;http;inline
+	Type SourceType `json:"type"`
+
+	// URL is the location of the assets, in the form expected by the type of the source. It is unused for an inline source
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Ref is the branch, tag or commit to serve for a Git source, the default branch of the repository is used when empty
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Directory is the sub-directory of the Git repository containing the assets, the root of the repository is used when empty
+	// +optional
+	Directory string `json:"directory,omitempty"`
+
+	// Sha256 is the expected SHA-256 checksum of the archive, for an HTTP source; the pods fail to start
+	// if the archive does not match
+	// +optional
+	Sha256 string `json:"sha256,omitempty"`
+
+	// Credentials references a Secret giving access to a private source, in the format needed by the type of the source:
+	// `key.json` (service account key) for `gcs`, `AWS_ACCESS_KEY_ID` and `AWS_SECRET_ACCESS_KEY` for `s3`,
+	// `username` and `password` (or token), or `ssh-privatekey` for `git`, `.dockerconfigjson` for `oci`,
+	// and `token` (sent as bearer token) for `http`
+	// +optional
+	Credentials *corev1.LocalObjectReference `json:"credentials,omitempty"`
+
+	// S3 configures the access to the S3-compatible storage, for an S3 source
+	// +optional
+	S3 *S3Source `json:"s3,omitempty"`
+
+	// ConfigMaps are ConfigMaps whose keys are served as files, for an inline source
+	// +optional
+	ConfigMaps []corev1.LocalObjectReference `json:"configMaps,omitempty"`
+
+	// Secrets are Secrets whose keys are served as files, for an inline source
+	// +optional
+	Secrets []corev1.LocalObjectReference `json:"secrets,omitempty"`
+}
+
+// StaticStatus defines the observed state of Static
+type StaticStatus struct {
+	// EXternalIP is the external IP of the load balancer, or its hostname for the load balancers without IP
+	ExternalIP string `json:"externalIP,omitempty"`
+
+	// LoadBalancerAddresses are all the IPs and hostnames of the load balancer
+	// +optional
+	LoadBalancerAddresses []string `json:"loadBalancerAddresses,omitempty"`
+
+	// IngressAddress is the IP or hostname of the load balancer of the Ingress, when exposed through an Ingress
+	// +optional
+	IngressAddress string `json:"ingressAddress,omitempty"`
+
+	// Hosts are the hostnames served by the Ingress
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// CertificateNotAfter is the expiration time of the certificate issued for the hostnames
+	// +optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+
+	// CertificateSecretName is the name of the Secret containing the certificate issued for the hostnames.
+	// With a Gateway, the listener of the Gateway must reference this Secret (with a ReferenceGrant
+	// when the Gateway is in another namespace), the operator does not modify the Gateway
+	// +optional
+	CertificateSecretName string `json:"certificateSecretName,omitempty"`
+
+	// SharedClaimName is the name of the PersistentVolumeClaim populated by the operator and served by the instances
+	// +optional
+	SharedClaimName string `json:"sharedClaimName,omitempty"`
+
+	// Replicas is the number of replicated pods
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Commit is the SHA of the Git commit being served, for a Git source
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// ContentHash is the fingerprint of the content of the source at the last check
+	// +optional
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// ChangedContentHash is the fingerprint of the content of the source after its last change detected by a check.
+	// It stays empty while the content matches the first check, the instances serving it already
+	// +optional
+	ChangedContentHash string `json:"changedContentHash,omitempty"`
+
+	// LastSyncTime is the last time the assets have been successfully copied again by the sync sidecar
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// SyncedObjects is the number of files copied by the last successful sync
+	// +optional
+	SyncedObjects int32 `json:"syncedObjects,omitempty"`
+
+	// URL is the URL of the site, when it is reachable
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// ObservedGeneration is the generation of the spec of the Static last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the current state of the Static
+	// +optional
+	Conditions []StaticCondition `json:"conditions,omitempty"`
+}
+
+// StaticConditionType is the type of a condition of a Static
+type StaticConditionType string
+
+const (
+	// ContentFetched indicates whether the assets have been copied from the source
+	ContentFetched StaticConditionType = "ContentFetched"
+
+	// SharedVolumePopulated indicates whether the shared volume contains the assets
+	SharedVolumePopulated StaticConditionType = "SharedVolumePopulated"
+
+	// RouteAccepted indicates whether the HTTPRoute has been accepted by its parent Gateway
+	RouteAccepted StaticConditionType = "RouteAccepted"
+
+	// CertificateReady indicates whether the certificate for the hostnames has been issued
+	CertificateReady StaticConditionType = "CertificateReady"
+
+	// DeploymentAvailable indicates whether the minimum number of pods serving the assets are available
+	DeploymentAvailable StaticConditionType = "DeploymentAvailable"
+
+	// ServiceReady indicates whether the Service exposing the pods is ready, with an address for a load balancer
+	ServiceReady StaticConditionType = "ServiceReady"
+
+	// Ready indicates whether the site is served, the other conditions being satisfied
+	Ready StaticConditionType = "Ready"
+)
+
+// StaticCondition describes the state of a Static at a certain point
+type StaticCondition struct {
+	// Type is the type of the condition
+	Type StaticConditionType `json:"type"`
+
+	// Status is the status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+
+	// LastTransitionTime is the last time the condition transitioned from one status to another
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a short, machine understandable string giving the reason of the last transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable message indicating details about the last transition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.url`
+// +kubebuilder:printcolumn:name="Min Replicas",type=string,JSONPath=`.spec.minReplicas`
+// +kubebuilder:printcolumn:name="Max Replicas",type=string,JSONPath=`.spec.maxReplicas`
+// +kubebuilder:printcolumn:name="Replicas",type=string,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="External IP",type=string,JSONPath=`.status.externalIP`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+
+// Static is the Schema for the statics API
+type Static struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StaticSpec   `json:"spec,omitempty"`
+	Status StaticStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StaticList contains a list of Static
+type StaticList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Static `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Static{}, &StaticList{})
+}
@@ -0,0 +1,278 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var staticlog = logf.Log.WithName("static-resource")
+
+// StaticDefaults are the defaults of the optional fields of the Statics, taken from the configuration of the operator
+// +kubebuilder:object:generate=false
+type StaticDefaults struct {
+	MaxReplicas int32
+	DiskSize    resource.Quantity
+}
+
+// staticDefaults are the defaults applied by the webhook. They are set once by SetupWebhookWithManager,
+// before the webhook is served, and only read afterwards
+var staticDefaults = StaticDefaults{
+	MaxReplicas: 4,
+	DiskSize:    resource.MustParse("20Mi"),
+}
+
+func (r *Static) SetupWebhookWithManager(mgr ctrl.Manager, defaults StaticDefaults) error {
+	staticDefaults = defaults
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-website-example-com-v1beta1-static,mutating=true,failurePolicy=fail,groups=website.example.com,resources=statics,verbs=create;update,versions=v1beta1,name=mstatic.v1beta1.kb.io
+
+var _ webhook.Defaulter = &Static{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *Static) Default() {
+	staticlog.Info("default", "name", r.Name)
+
+	// The fields are defaulted here only, the CRD being served in apiextensions.k8s.io/v1beta1 without defaults
+	if r.Spec.MinReplicas == 0 {
+		r.Spec.MinReplicas = 1
+	}
+	if r.Spec.MaxReplicas == 0 {
+		r.Spec.MaxReplicas = staticDefaults.MaxReplicas
+		if r.Spec.MaxReplicas < r.Spec.MinReplicas {
+			r.Spec.MaxReplicas = r.Spec.MinReplicas
+		}
+	}
+	if r.Spec.DiskSize.IsZero() {
+		r.Spec.DiskSize = staticDefaults.DiskSize.DeepCopy()
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-website-example-com-v1beta1-static,mutating=false,failurePolicy=fail,groups=website.example.com,resources=statics,versions=v1beta1,name=vstatic.v1beta1.kb.io
+
+var _ webhook.Validator = &Static{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateCreate() error {
+	staticlog.Info("validate create", "name", r.Name)
+
+	return r.validateStatic()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateUpdate(old runtime.Object) error {
+	staticlog.Info("validate update", "name", r.Name)
+
+	return r.validateStatic()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Static) ValidateDelete() error {
+	return nil
+}
+
+func (r *Static) validateStatic() error {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, r.validateReplicas()...)
+	allErrs = append(allErrs, r.validateDiskSize()...)
+	allErrs = append(allErrs, r.validateSource()...)
+	allErrs = append(allErrs, r.validateIntervals()...)
+	allErrs = append(allErrs, r.validateHeaders()...)
+	allErrs = append(allErrs, r.validateRedirects()...)
+	allErrs = append(allErrs, r.validateHTTPS()...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Static").GroupKind(), r.Name, allErrs)
+}
+
+// validateReplicas checks the bounds of the HPA, which would be rejected when creating it
+func (r *Static) validateReplicas() field.ErrorList {
+	var allErrs field.ErrorList
+	spec := field.NewPath("spec")
+	if r.Spec.MinReplicas <= 0 {
+		allErrs = append(allErrs, field.Invalid(spec.Child("minReplicas"), r.Spec.MinReplicas, "must be greater than 0"))
+	}
+	if r.Spec.MaxReplicas <= 0 {
+		allErrs = append(allErrs, field.Invalid(spec.Child("maxReplicas"), r.Spec.MaxReplicas, "must be greater than 0"))
+	} else if r.Spec.MinReplicas > r.Spec.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(spec.Child("maxReplicas"), r.Spec.MaxReplicas, "must be greater than or equal to minReplicas"))
+	}
+	return allErrs
+}
+
+// validateDiskSize checks that some disk space is reserved for the assets
+func (r *Static) validateDiskSize() field.ErrorList {
+	if r.Spec.DiskSize.Sign() <= 0 {
+		return field.ErrorList{field.Invalid(field.NewPath("spec").Child("diskSize"), r.Spec.DiskSize.String(), "must be greater than 0")}
+	}
+	return nil
+}
+
+// sourceSchemes are the schemes of the URLs supported by each type of source copying the assets from a URL
+var sourceSchemes = map[SourceType][]string{
+	SourceTypeGCS:  {"gs"},
+	SourceTypeS3:   {"s3"},
+	SourceTypeOCI:  {"oci"},
+	SourceTypeHTTP: {"http", "https"},
+}
+
+// supportedSourceTypes are the types of the sources the assets can be copied from
+var supportedSourceTypes = []string{
+	string(SourceTypeGCS), string(SourceTypeS3), string(SourceTypeGit),
+	string(SourceTypeOCI), string(SourceTypeHTTP), string(SourceTypeInline),
+}
+
+// validateSource checks that the source gives what its type needs to copy the assets
+func (r *Static) validateSource() field.ErrorList {
+	source := r.Spec.Source
+	path := field.NewPath("spec").Child("source")
+	switch source.Type {
+	case "":
+		return field.ErrorList{field.Required(path.Child("type"), "the type of the source is required")}
+	case SourceTypeInline:
+		if len(source.ConfigMaps) == 0 && len(source.Secrets) == 0 {
+			return field.ErrorList{field.Required(path.Child("configMaps"), "configMaps or secrets are required for an inline source")}
+		}
+		return nil
+	case SourceTypeGit:
+		if source.URL == "" {
+			return field.ErrorList{field.Required(path.Child("url"), "the URL of the repository is required for a git source")}
+		}
+		return nil
+	}
+
+	schemes, found := sourceSchemes[source.Type]
+	if !found {
+		return field.ErrorList{field.NotSupported(path.Child("type"), source.Type, supportedSourceTypes)}
+	}
+	if source.URL == "" {
+		return field.ErrorList{field.Required(path.Child("url"), fmt.Sprintf("the URL of the assets is required for a %s source", source.Type))}
+	}
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path.Child("url"), source.URL, fmt.Sprintf("malformed URL: %v", err))}
+	}
+	supported := false
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			supported = true
+		}
+	}
+	if !supported {
+		return field.ErrorList{field.NotSupported(path.Child("url").Key("scheme"), u.Scheme, schemes)}
+	}
+	if u.Host == "" {
+		return field.ErrorList{field.Invalid(path.Child("url"), source.URL, "must include a bucket, a registry or a host")}
+	}
+	return nil
+}
+
+// minInterval is the minimum interval between two copies or two checks of the assets
+const minInterval = time.Minute
+
+// validateIntervals checks that the assets are not copied or checked again in a tight loop
+func (r *Static) validateIntervals() field.ErrorList {
+	var allErrs field.ErrorList
+	spec := field.NewPath("spec")
+	if interval := r.Spec.SyncInterval; interval != nil && interval.Duration < minInterval {
+		allErrs = append(allErrs, field.Invalid(spec.Child("syncInterval"), interval.Duration.String(), fmt.Sprintf("must be at least %s", minInterval)))
+	}
+	if interval := r.Spec.PollInterval; interval != nil && interval.Duration < minInterval {
+		allErrs = append(allErrs, field.Invalid(spec.Child("pollInterval"), interval.Duration.String(), fmt.Sprintf("must be at least %s", minInterval)))
+	}
+	return allErrs
+}
+
+// validateHeaders checks the names and the values of the response headers,
+// so that they cannot break the generated configuration of nginx
+func (r *Static) validateHeaders() field.ErrorList {
+	var allErrs field.ErrorList
+	for i, rule := range r.Spec.Headers {
+		if rule.Regex {
+			if _, err := regexp.Compile(rule.Path); err != nil {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("headers").Index(i).Child("path"), rule.Path, err.Error()))
+			}
+		}
+		path := field.NewPath("spec").Child("headers").Index(i).Child("values")
+		for name, value := range rule.Values {
+			for _, msg := range validation.IsHTTPHeaderName(name) {
+				allErrs = append(allErrs, field.Invalid(path.Key(name), name, msg))
+			}
+			if err := validateHeaderValue(value); err != "" {
+				allErrs = append(allErrs, field.Invalid(path.Key(name), value, err))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateHTTPS checks that a certificate is available to serve HTTPS: without a Secret,
+// nginx would mount the Secret of a certificate which is not requested to cert-manager
+func (r *Static) validateHTTPS() field.ErrorList {
+	if r.Spec.HTTPS == nil || r.Spec.HTTPS.SecretName != "" || r.Spec.TLS != nil {
+		return nil
+	}
+	return field.ErrorList{field.Required(field.NewPath("spec").Child("https").Child("secretName"), "the secret of the certificate is required when tls is not set")}
+}
+
+// validateRedirects checks the regular expressions of the redirects, which would fail the reload of nginx
+func (r *Static) validateRedirects() field.ErrorList {
+	var allErrs field.ErrorList
+	for i, redirect := range r.Spec.Redirects {
+		if !redirect.Regex {
+			continue
+		}
+		if _, err := regexp.Compile(redirect.From); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("redirects").Index(i).Child("from"), redirect.From, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateHeaderValue returns why a value is not a valid header field value (RFC 7230), if it is not,
+// or why nginx would not accept it
+func validateHeaderValue(value string) string {
+	for _, c := range value {
+		if (c < ' ' && c != '\t') || c == 0x7f {
+			return fmt.Sprintf("a header value must not contain control characters, found %q", c)
+		}
+		if c == '$' {
+			// nginx expands the variables in the values of its maps, and fails on unknown ones
+			return "a header value must not contain $"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validStatic returns a Static accepted by the webhook
+func validStatic() *Static {
+	return &Static{
+		Spec: StaticSpec{
+			DiskSize:    resource.MustParse("20Mi"),
+			Source:      Source{Type: SourceTypeGCS, URL: "gs://my-bucket/docs"},
+			MinReplicas: 1,
+			MaxReplicas: 2,
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(static *Static)
+		invalid bool
+	}{
+		{
+			name:   "valid static",
+			mutate: func(static *Static) {},
+		},
+		{
+			name: "inline source",
+			mutate: func(static *Static) {
+				static.Spec.Source = Source{Type: SourceTypeInline, ConfigMaps: []corev1.LocalObjectReference{{Name: "my-site"}}}
+			},
+		},
+		{
+			name: "inline source without configMaps or secrets",
+			mutate: func(static *Static) {
+				static.Spec.Source = Source{Type: SourceTypeInline}
+			},
+			invalid: true,
+		},
+		{
+			name: "git source",
+			mutate: func(static *Static) {
+				static.Spec.Source = Source{Type: SourceTypeGit, URL: "git@github.com:example/docs.git", Ref: "main"}
+			},
+		},
+		{
+			name: "git source without URL",
+			mutate: func(static *Static) {
+				static.Spec.Source = Source{Type: SourceTypeGit}
+			},
+			invalid: true,
+		},
+		{
+			name: "https URL for an http source",
+			mutate: func(static *Static) {
+				static.Spec.Source = Source{Type: SourceTypeHTTP, URL: "https://example.com/site.tar.gz"}
+			},
+		},
+		{
+			name: "missing type",
+			mutate: func(static *Static) {
+				static.Spec.Source.Type = ""
+			},
+			invalid: true,
+		},
+		{
+			name: "unsupported type",
+			mutate: func(static *Static) {
+				static.Spec.Source.Type = "ftp"
+			},
+			invalid: true,
+		},
+		{
+			name: "scheme not matching the type",
+			mutate: func(static *Static) {
+				static.Spec.Source.Type = SourceTypeS3
+			},
+			invalid: true,
+		},
+		{
+			name: "missing URL",
+			mutate: func(static *Static) {
+				static.Spec.Source.URL = ""
+			},
+			invalid: true,
+		},
+		{
+			name: "malformed URL",
+			mutate: func(static *Static) {
+				static.Spec.Source.URL = "gs://my bucket%/docs"
+			},
+			invalid: true,
+		},
+		{
+			name: "URL without bucket",
+			mutate: func(static *Static) {
+				static.Spec.Source.URL = "gs:///docs"
+			},
+			invalid: true,
+		},
+		{
+			name: "minReplicas greater than maxReplicas",
+			mutate: func(static *Static) {
+				static.Spec.MinReplicas = 3
+			},
+			invalid: true,
+		},
+		{
+			name: "sync interval of one hour",
+			mutate: func(static *Static) {
+				static.Spec.SyncInterval = &metav1.Duration{Duration: time.Hour}
+			},
+		},
+		{
+			name: "sync interval too short",
+			mutate: func(static *Static) {
+				static.Spec.SyncInterval = &metav1.Duration{Duration: 500 * time.Millisecond}
+			},
+			invalid: true,
+		},
+		{
+			name: "poll interval of one hour",
+			mutate: func(static *Static) {
+				static.Spec.PollInterval = &metav1.Duration{Duration: time.Hour}
+			},
+		},
+		{
+			name: "poll interval too short",
+			mutate: func(static *Static) {
+				static.Spec.PollInterval = &metav1.Duration{Duration: time.Second}
+			},
+			invalid: true,
+		},
+		{
+			name: "zero diskSize",
+			mutate: func(static *Static) {
+				static.Spec.DiskSize = resource.MustParse("0")
+			},
+			invalid: true,
+		},
+		{
+			name: "invalid header name",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"X-Frame Options": "DENY"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "header value with a variable",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/", Values: map[string]string{"Cache-Control": "max-age=$foo"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "valid regular expressions",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: `\.(js|css)$`, Regex: true, Values: map[string]string{"Access-Control-Allow-Origin": "*"}}}
+				static.Spec.Redirects = []Redirect{{From: "^/blog/(.*)$", To: "/posts/$1", Regex: true}}
+			},
+		},
+		{
+			name: "invalid header path regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Headers = []ResponseHeaders{{Path: "/(assets", Regex: true, Values: map[string]string{"Access-Control-Allow-Origin": "*"}}}
+			},
+			invalid: true,
+		},
+		{
+			name: "https with the secret of a certificate",
+			mutate: func(static *Static) {
+				static.Spec.HTTPS = &HTTPS{SecretName: "docs-example-com-tls"}
+			},
+		},
+		{
+			name: "https with a certificate issued by cert-manager",
+			mutate: func(static *Static) {
+				static.Spec.Ingress = &Ingress{Hosts: []string{"docs.example.com"}}
+				static.Spec.TLS = &TLS{}
+				static.Spec.HTTPS = &HTTPS{}
+			},
+		},
+		{
+			name: "https without certificate",
+			mutate: func(static *Static) {
+				static.Spec.HTTPS = &HTTPS{RedirectHTTP: true}
+			},
+			invalid: true,
+		},
+		{
+			name: "invalid redirect regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Redirects = []Redirect{{From: "^/blog/[a-", To: "/posts/", Regex: true}}
+			},
+			invalid: true,
+		},
+		{
+			name: "redirect path not interpreted as a regular expression",
+			mutate: func(static *Static) {
+				static.Spec.Redirects = []Redirect{{From: "/blog/[a-", To: "/posts/"}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static := validStatic()
+			tt.mutate(static)
+			if err := static.ValidateCreate(); (err != nil) != tt.invalid {
+				t.Errorf("ValidateCreate() error = %v, invalid %v", err, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	staticDefaults = StaticDefaults{
+		MaxReplicas: 4,
+		DiskSize:    resource.MustParse("20Mi"),
+	}
+
+	static := &Static{}
+	static.Default()
+	if static.Spec.MinReplicas != 1 || static.Spec.MaxReplicas != 4 || static.Spec.DiskSize.String() != "20Mi" {
+		t.Errorf("Default() = %d, %d, %s, want 1, 4, 20Mi", static.Spec.MinReplicas, static.Spec.MaxReplicas, static.Spec.DiskSize.String())
+	}
+}
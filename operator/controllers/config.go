@@ -1,9 +1,53 @@
 package controllers
 
+import (
+	"fmt"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
 type StaticConfiguration struct {
 	MemoryRequestMi int64 `default:"32" split_words:"true"`
 	MemoryLimitMi   int64 `default:"128" split_words:"true"`
 	CpuRequestMilli int64 `default:"100" split_words:"true"`
 	CpuLimitMilli   int64 `default:"500" split_words:"true"`
 	CpuUtilization  int32 `default:"400" split_words:"true"`
+
+	GcsFetcherImage  string `default:"gcr.io/cloud-builders/gcloud" split_words:"true"`
+	S3FetcherImage   string `default:"amazon/aws-cli" split_words:"true"`
+	GitFetcherImage  string `default:"alpine/git" split_words:"true"`
+	OciFetcherImage  string `default:"gcr.io/go-containerregistry/crane:debug" split_words:"true"`
+	HttpFetcherImage string `default:"alpine" split_words:"true"`
+
+	TlsIssuerName string `default:"letsencrypt" split_words:"true"`
+	TlsIssuerKind string `default:"ClusterIssuer" split_words:"true"`
+
+	DefaultMaxReplicas int32  `default:"4" split_words:"true"`
+	DefaultDiskSize    string `default:"20Mi" split_words:"true"`
+
+	// WatchNamespace limits the Statics reconciled, and the objects cached, to a namespace. All the namespaces when empty
+	WatchNamespace string `split_words:"true"`
+}
+
+// setDefaults sets in memory the defaults of the optional fields of the Static. They are set by the mutating webhook,
+// unless the Static has been stored without it, e.g. when the webhooks are disabled to run the operator locally
+func (r *StaticReconciler) setDefaults(static *websitev1beta1.Static) error {
+	if static.Spec.MinReplicas == 0 {
+		static.Spec.MinReplicas = 1
+	}
+	if static.Spec.MaxReplicas == 0 {
+		static.Spec.MaxReplicas = r.Config.DefaultMaxReplicas
+		if static.Spec.MaxReplicas < static.Spec.MinReplicas {
+			static.Spec.MaxReplicas = static.Spec.MinReplicas
+		}
+	}
+	if static.Spec.DiskSize.IsZero() {
+		diskSize, err := resource.ParseQuantity(r.Config.DefaultDiskSize)
+		if err != nil {
+			return fmt.Errorf("invalid default disk size: %v", err)
+		}
+		static.Spec.DiskSize = diskSize
+	}
+	return nil
 }
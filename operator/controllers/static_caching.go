@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+)
+
+// globToRegex converts the glob of a cache rule to a regular expression matching the paths
+func globToRegex(glob string) string {
+	var regex strings.Builder
+	if strings.Contains(glob, "/") {
+		regex.WriteString("^")
+	} else {
+		// The pattern matches the last segment of the paths
+		regex.WriteString("(^|/)")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			regex.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			regex.WriteString("[^/]*")
+		case glob[i] == '?':
+			regex.WriteString("[^/]")
+		default:
+			regex.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	regex.WriteString("$")
+	return regex.String()
+}
+
+// cacheControl returns the Cache-Control header of the responses matching a cache rule
+func cacheControl(rule websitev1beta1.CacheRule) string {
+	if rule.NoCache {
+		return "no-cache"
+	}
+	maxAge := time.Duration(0)
+	if rule.MaxAge != nil {
+		maxAge = rule.MaxAge.Duration
+	}
+	value := fmt.Sprintf("public, max-age=%d", int64(maxAge/time.Second))
+	if rule.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+// sendsETag returns true if nginx sends the ETag header for the Static
+func sendsETag(static *websitev1beta1.Static) bool {
+	caching := static.Spec.Caching
+	return caching == nil || caching.ETag == nil || *caching.ETag
+}
+
+// sendsLastModified returns true if nginx sends the Last-Modified header for the Static
+func sendsLastModified(static *websitev1beta1.Static) bool {
+	caching := static.Spec.Caching
+	return caching == nil || caching.LastModified == nil || *caching.LastModified
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// certificateGroupKind is the group and kind of the Certificates of cert-manager,
+// handled as unstructured objects
+var certificateGroupKind = schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"}
+
+// tlsSecretName returns the name of the Secret containing the certificate issued for the hostnames of the Static
+func tlsSecretName(static *websitev1beta1.Static) string {
+	return static.Name + "-tls"
+}
+
+// tlsHosts returns the hostnames of the Ingress and the Gateway of the Static, to be served over HTTPS
+func tlsHosts(static *websitev1beta1.Static) []string {
+	hosts := []string{}
+	seen := map[string]bool{}
+	add := func(names []string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				hosts = append(hosts, name)
+			}
+		}
+	}
+	if static.Spec.Ingress != nil {
+		add(static.Spec.Ingress.Hosts)
+	}
+	if static.Spec.Gateway != nil {
+		add(static.Spec.Gateway.Hostnames)
+	}
+	return hosts
+}
+
+func (r *StaticReconciler) applyCertificate(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	if r.certificateGVK == nil {
+		// The spec cannot be honored until cert-manager is installed and the operator restarted
+		return r.clearCertificateStatus(ctx, static, "CertManagerMissing", "cert-manager-missing", "The certificate cannot be requested: cert-manager is not installed")
+	}
+
+	name := static.Name + "-certificate"
+
+	// Get the existing Certificate from cluster, if any
+	found := new(unstructured.Unstructured)
+	found.SetGroupVersionKind(*r.certificateGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: static.Namespace}, found)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			// Error trying to get
+			return err
+		}
+		found = nil
+	}
+
+	if static.Spec.TLS == nil || len(tlsHosts(static)) == 0 {
+		// The spec needs to be fixed when TLS is requested, there is no need to requeue
+		if err = r.clearCertificateStatus(ctx, static, "NoHostnames", "tls-without-hosts", "The certificate cannot be requested: no hostname is declared in the ingress or the gateway"); err != nil {
+			return err
+		}
+		if found == nil || !metav1.IsControlledBy(found, static) {
+			return nil
+		}
+
+		log.Info("Certificate found but not expected => Delete certificate")
+
+		err = r.Delete(ctx, found)
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-certificate", "The certificate '%s.%s' has been deleted", found.GetNamespace(), found.GetName())
+		return nil
+	}
+
+	// Create in memory the Certificate that is expected to exist into cluster
+	expected := r.createCertificate(static)
+
+	if found != nil {
+		// Certificate exists in cluster
+
+		if err = r.applyCertificateStatus(ctx, log, static, found); err != nil {
+			return err
+		}
+
+		if !equality.Semantic.DeepDerivative(expected.Object["spec"], found.Object["spec"]) {
+
+			log.Info("Certificate found but different than expected => Update certificate")
+
+			found.Object["spec"] = expected.Object["spec"]
+			controllerutil.SetControllerReference(static, found, r.Scheme)
+			err = r.Update(ctx, found)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-certificate", "The certificate '%s.%s' has been updated due to unexpected change", expected.GetNamespace(), expected.GetName())
+		}
+		return nil
+	}
+
+	log.Info("Certificate not found => Create certificate")
+
+	// Set static as parent of certificate
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create certificate for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-certificate", "The certificate '%s.%s' has been created", expected.GetNamespace(), expected.GetName())
+
+	return nil
+}
+
+// clearCertificateStatus removes the issued certificate from the status of the Static. When TLS is requested,
+// the CertificateReady condition reports why the certificate cannot be requested, with a warning when it changes;
+// otherwise the condition is removed
+func (r *StaticReconciler) clearCertificateStatus(ctx context.Context, static *websitev1beta1.Static, reason, eventReason, message string) error {
+
+	changed, warn := false, false
+	if static.Spec.TLS != nil {
+		warn = setCondition(static, websitev1beta1.CertificateReady, corev1.ConditionFalse, reason, message)
+		changed = warn
+	} else {
+		changed = removeCondition(static, websitev1beta1.CertificateReady)
+	}
+	if static.Status.CertificateNotAfter != nil || static.Status.CertificateSecretName != "" {
+		static.Status.CertificateNotAfter = nil
+		static.Status.CertificateSecretName = ""
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := r.Status().Update(ctx, static); err != nil {
+		return err
+	}
+
+	if warn {
+		r.Recorder.Eventf(static, corev1.EventTypeWarning, eventReason, "%s", message)
+	}
+	return nil
+}
+
+// applyCertificateStatus reports the readiness and the expiration of the certificate in the status of the Static
+func (r *StaticReconciler) applyCertificateStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, certificate *unstructured.Unstructured) error {
+
+	changed := false
+
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ := unstructured.NestedString(condition, "message")
+		if setCondition(static, websitev1beta1.CertificateReady, corev1.ConditionStatus(status), reason, message) {
+			log.Info(fmt.Sprintf("Certificate ready: %s (%s)", status, reason))
+			changed = true
+		}
+	}
+
+	var notAfter *metav1.Time
+	if value, found, _ := unstructured.NestedString(certificate.Object, "status", "notAfter"); found {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			notAfter = &metav1.Time{Time: t}
+		}
+	}
+	if !equality.Semantic.DeepEqual(notAfter, static.Status.CertificateNotAfter) {
+		static.Status.CertificateNotAfter = notAfter
+		changed = true
+	}
+
+	// The Gateway is not owned by the Static, its owner references the Secret in the listener
+	if static.Status.CertificateSecretName != tlsSecretName(static) {
+		static.Status.CertificateSecretName = tlsSecretName(static)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, static)
+}
+
+func (r *StaticReconciler) createCertificate(static *websitev1beta1.Static) *unstructured.Unstructured {
+	issuerName, issuerKind := static.Spec.TLS.IssuerName, static.Spec.TLS.IssuerKind
+	if issuerName == "" {
+		issuerName = r.Config.TlsIssuerName
+	}
+	if issuerKind == "" {
+		issuerKind = r.Config.TlsIssuerKind
+	}
+
+	dnsNames := []interface{}{}
+	for _, host := range tlsHosts(static) {
+		dnsNames = append(dnsNames, host)
+	}
+
+	certificate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretName": tlsSecretName(static),
+				"dnsNames":   dnsNames,
+				"issuerRef": map[string]interface{}{
+					"name":  issuerName,
+					"kind":  issuerKind,
+					"group": certificateGroupKind.Group,
+				},
+			},
+		},
+	}
+	certificate.SetGroupVersionKind(*r.certificateGVK)
+	certificate.SetName(static.Name + "-certificate")
+	certificate.SetNamespace(static.Namespace)
+	return certificate
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// applyContentCheck runs periodically a Job checking the content of the source of the Static, reports the fingerprint
+// of the content in the status of the Static, and returns the duration after which the next check is due
+func (r *StaticReconciler) applyContentCheck(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) (time.Duration, error) {
+
+	if static.Spec.PollInterval == nil || isInline(static) {
+		return 0, nil
+	}
+
+	// Create in memory the Job that is expected to exist into cluster
+	expected, err := r.createCheckJob(static)
+	if err != nil {
+		// The spec needs to be fixed, the error is reported when building the deployment
+		return 0, nil
+	}
+
+	// Get the existing Job from cluster, if any
+	found := new(batchv1.Job)
+	err = r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			// Error trying to get
+			return 0, err
+		}
+
+		// No event is recorded, as a new Job is created at each interval
+		log.Info("Check job not found => Create check job")
+
+		// Set static as parent of job
+		controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+		if err = r.Create(ctx, expected); err != nil {
+			log.Error(err, "unable to create check job for static")
+		}
+		return 0, nil
+	}
+
+	if found.Annotations[sourceHashAnnotation] != expected.Annotations[sourceHashAnnotation] {
+		// The source has changed since the Job has been created, its result is obsolete
+		log.Info("Check job found but with a different source => Delete check job")
+		return 0, client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+	}
+
+	var finished *metav1.Time
+	if found.Status.Succeeded > 0 {
+		finished = found.Status.CompletionTime
+
+		hash, err := r.checkJobFingerprint(ctx, found)
+		if err != nil {
+			return 0, err
+		}
+		if hash != "" && hash != static.Status.ContentHash {
+			changed := static.Status.ContentHash != ""
+			log.Info(fmt.Sprintf("New content hash: %s", hash))
+			static.Status.ContentHash = hash
+			if changed {
+				// The first fingerprint describes the content already served
+				static.Status.ChangedContentHash = hash
+			}
+			err = r.Status().Update(ctx, static)
+			if err != nil {
+				return 0, err
+			}
+			if changed {
+				r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-content", "The content of the source has changed, the pods will be restarted")
+			}
+		}
+	}
+	for _, condition := range found.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			log.Info(fmt.Sprintf("Content check failed: %s", condition.Message))
+			finished = &condition.LastTransitionTime
+		}
+	}
+	if finished == nil {
+		// The Job is still running
+		return 0, nil
+	}
+
+	if remaining := time.Until(finished.Add(static.Spec.PollInterval.Duration)); remaining > 0 {
+		return remaining, nil
+	}
+
+	// The Job is created again after its deletion
+	log.Info("Check job expired => Delete check job")
+	return 0, client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// checkJobFingerprint returns the fingerprint written by the succeeded pod of the check Job, if any
+func (r *StaticReconciler) checkJobFingerprint(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := new(corev1.PodList)
+	err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != fingerprinterContainerName {
+				continue
+			}
+			if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode == 0 {
+				return strings.TrimSpace(terminated.Message), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (r *StaticReconciler) createCheckJob(static *websitev1beta1.Static) (*batchv1.Job, error) {
+	name := static.Name + "-check-job"
+
+	fingerprinter, err := r.createFingerprinter(static)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialsVolumes := createCredentialsVolumes(static)
+
+	hash, err := json.Marshal([]interface{}{fingerprinter, credentialsVolumes})
+	if err != nil {
+		return nil, err
+	}
+
+	// A failing check is retried by the Job of the next interval
+	backoffLimit := int32(2)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:      name,
+			Namespace: static.Namespace,
+			Annotations: map[string]string{
+				sourceHashAnnotation: fmt.Sprintf("%x", sha256.Sum256(hash)),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: serviceAccountName(static),
+					Volumes:            credentialsVolumes,
+					Containers: []corev1.Container{
+						*fingerprinter,
+					},
+				},
+			},
+		},
+	}, nil
+}
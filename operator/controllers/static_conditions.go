@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// findCondition returns the condition of the given type from the status of a Static, if any
+func findCondition(static *websitev1beta1.Static, conditionType websitev1beta1.StaticConditionType) *websitev1beta1.StaticCondition {
+	for i := range static.Status.Conditions {
+		if static.Status.Conditions[i].Type == conditionType {
+			return &static.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setCondition sets a condition in the status of a Static and returns true if the condition has changed.
+// The transition time is only updated when the status of the condition changes.
+func setCondition(static *websitev1beta1.Static, conditionType websitev1beta1.StaticConditionType, status corev1.ConditionStatus, reason, message string) bool {
+	existing := findCondition(static, conditionType)
+	if existing == nil {
+		static.Status.Conditions = append(static.Status.Conditions, websitev1beta1.StaticCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.Now(),
+			Reason:             reason,
+			Message:            message,
+		})
+		return true
+	}
+
+	if existing.Status == status && existing.Reason == reason && existing.Message == message {
+		return false
+	}
+	if existing.Status != status {
+		existing.LastTransitionTime = metav1.Now()
+	}
+	existing.Status = status
+	existing.Reason = reason
+	existing.Message = message
+	return true
+}
+
+// removeCondition removes a condition from the status of a Static and returns true if it was present
+func removeCondition(static *websitev1beta1.Static, conditionType websitev1beta1.StaticConditionType) bool {
+	for i := range static.Status.Conditions {
+		if static.Status.Conditions[i].Type == conditionType {
+			static.Status.Conditions = append(static.Status.Conditions[:i], static.Status.Conditions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func (r *StaticReconciler) applyContentStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	fetched, err := r.lastFetcherState(ctx, static)
+	if err != nil {
+		return err
+	}
+	if fetched == nil {
+		// No fetcher has terminated yet
+		return nil
+	}
+
+	if fetched.ExitCode != 0 {
+		message := strings.TrimSpace(fetched.Message)
+		reason, eventReason := "FetchFailed", "fetch-failed"
+		if strings.HasPrefix(message, checksumMismatch) {
+			reason, eventReason = "ChecksumMismatch", "checksum-mismatch"
+		}
+
+		if setCondition(static, websitev1beta1.ContentFetched, corev1.ConditionFalse, reason, message) {
+			log.Info(fmt.Sprintf("Content not fetched: %s", message))
+			err = r.Status().Update(ctx, static)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeWarning, eventReason, "The assets cannot be copied from the source: %s", message)
+		}
+		return nil
+	}
+
+	changed := setCondition(static, websitev1beta1.ContentFetched, corev1.ConditionTrue, "Fetched", "The assets have been copied from the source")
+
+	// The git fetcher writes the SHA of the served commit as termination message
+	commit := ""
+	if static.Spec.Source.Type == websitev1beta1.SourceTypeGit {
+		commit = strings.TrimSpace(fetched.Message)
+	}
+	commitChanged := commit != "" && commit != static.Status.Commit
+	if commitChanged {
+		log.Info(fmt.Sprintf("New commit: %s", commit))
+		static.Status.Commit = commit
+	}
+
+	if changed || commitChanged {
+		err = r.Status().Update(ctx, static)
+		if err != nil {
+			return err
+		}
+	}
+
+	if commitChanged {
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-commit", "The served commit has been updated to %s", static.Status.Commit)
+	}
+	return nil
+}
+
+// podToStatic maps a pod created for a Static, by its Deployment or by the Job populating its shared volume,
+// to a request for this Static
+func podToStatic(pod handler.MapObject) []reconcile.Request {
+	var name string
+	if app := pod.Meta.GetLabels()["app"]; strings.HasSuffix(app, "-deployment") {
+		name = strings.TrimSuffix(app, "-deployment")
+	} else if job := pod.Meta.GetLabels()["job-name"]; strings.HasSuffix(job, "-job") {
+		name = strings.TrimSuffix(job, "-job")
+	} else {
+		return nil
+	}
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Name:      name,
+				Namespace: pod.Meta.GetNamespace(),
+			},
+		},
+	}
+}
+
+// lastFetcherState returns the termination state of the fetcher container of the most recent pod of the Static, if any.
+// The fetcher is an init container of the instances, or the container of the Job populating the shared volume
+func (r *StaticReconciler) lastFetcherState(ctx context.Context, static *websitev1beta1.Static) (*corev1.ContainerStateTerminated, error) {
+	labels := client.MatchingLabels{"app": static.Name + "-deployment"}
+	if static.Spec.SharedVolume != nil {
+		labels = client.MatchingLabels{"job-name": jobName(static)}
+	}
+	pods := new(corev1.PodList)
+	err := r.List(ctx, pods, client.InNamespace(static.Namespace), labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		last  *corev1.Pod
+		state *corev1.ContainerStateTerminated
+	)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if last != nil && !last.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		statuses := pod.Status.InitContainerStatuses
+		if static.Spec.SharedVolume != nil {
+			statuses = pod.Status.ContainerStatuses
+		}
+		for _, status := range statuses {
+			if status.Name != fetcherContainerName {
+				continue
+			}
+			terminated := status.State.Terminated
+			if terminated == nil {
+				// A failed fetcher waiting to be restarted reports its failure as last state
+				terminated = status.LastTerminationState.Terminated
+			}
+			if terminated != nil {
+				last, state = pod, terminated
+			}
+		}
+	}
+	return state, nil
+}
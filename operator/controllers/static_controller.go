@@ -23,36 +23,60 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 )
 
 // StaticReconciler reconciles a Static object
 type StaticReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Config   *StaticConfiguration
-	Recorder record.EventRecorder
+	// KubeClient reads the logs of the pods, not available from the controller-runtime client
+	KubeClient kubernetes.Interface
+	Log        logr.Logger
+	Scheme     *runtime.Scheme
+	Config     *StaticConfiguration
+	Recorder   record.EventRecorder
+
+	// httpRouteGVK is the version of the HTTPRoutes served by the cluster, nil if the Gateway API is not installed
+	httpRouteGVK *schema.GroupVersionKind
+	// certificateGVK is the version of the Certificates served by the cluster, nil if cert-manager is not installed
+	certificateGVK *schema.GroupVersionKind
 }
 
 // +kubebuilder:rbac:groups=website.example.com,resources=statics,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=website.example.com,resources=statics/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=events,verbscreate
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create
 
 func (r *StaticReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("static", req.NamespacedName)
 
-	static := new(websitev1alpha1.Static)
+	static := new(websitev1beta1.Static)
 
 	if err := r.Get(ctx, req.NamespacedName, static); err != nil {
 		// we'll ignore not-found errors, since they can't be fixed by an immediate
@@ -61,9 +85,41 @@ func (r *StaticReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		err = client.IgnoreNotFound(err)
 		return ctrl.Result{}, err
 	}
+	if err := r.setDefaults(static); err != nil {
+		return ctrl.Result{}, err
+	}
 	log.Info(fmt.Sprintf("static: %+v", static.Spec))
 
-	if err := r.applyDeployment(ctx, log, static); err != nil {
+	if err := r.applyServiceAccount(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	requeueAfter, err := r.applyContentCheck(ctx, log, static)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	populated, err := r.applySharedVolume(ctx, log, static)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applyNginxConfigMap(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Wait for the shared volume, if any, to be populated before serving it
+	if populated {
+		if err := r.applyDeployment(ctx, log, static); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.applyContentStatus(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applySyncStatus(ctx, log, static); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -71,18 +127,68 @@ func (r *StaticReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	if err := r.applyIngress(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applyHTTPRoute(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.applyCertificate(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err := r.applyHPA(ctx, log, static); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	if err := r.applyReadyStatus(ctx, log, static); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if sync := static.Spec.SyncInterval; sync != nil && (requeueAfter == 0 || sync.Duration < requeueAfter) {
+		// The sync status is read from the logs of the pods, which are not watched
+		requeueAfter = sync.Duration
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *StaticReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&websitev1alpha1.Static{}).
+	var err error
+	if r.httpRouteGVK, err = findGVK(mgr.GetRESTMapper(), httpRouteGroupKind); err != nil {
+		return err
+	}
+	if r.certificateGVK, err = findGVK(mgr.GetRESTMapper(), certificateGroupKind); err != nil {
+		return err
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&websitev1beta1.Static{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&autoscalingv1.HorizontalPodAutoscaler{}).
-		Complete(r)
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&batchv1.Job{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&networkingv1beta1.Ingress{}).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(podToStatic)}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.configMapToStatics)}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.secretToStatics)})
+
+	if r.httpRouteGVK != nil {
+		// The HTTPRoutes are owned only when the Gateway API is installed
+		route := new(unstructured.Unstructured)
+		route.SetGroupVersionKind(*r.httpRouteGVK)
+		builder = builder.Owns(route)
+	}
+	if r.certificateGVK != nil {
+		// The Certificates are owned only when cert-manager is installed
+		certificate := new(unstructured.Unstructured)
+		certificate.SetGroupVersionKind(*r.certificateGVK)
+		builder = builder.Owns(certificate)
+	}
+
+	return builder.Complete(r)
 }
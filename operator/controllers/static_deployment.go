@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -16,14 +16,42 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-func (r *StaticReconciler) applyDeployment(ctx context.Context, log logr.Logger, static *websitev1alpha1.Static) error {
+func (r *StaticReconciler) applyDeployment(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
 
 	// Create in memory the Deployment that is expected to exist into cluster
-	expected := r.createDeployment(static)
+	expected, err := r.createDeployment(static)
+	if err != nil {
+		// The spec needs to be fixed, there is no need to requeue
+		log.Error(err, "unable to build deployment for static")
+		r.Recorder.Eventf(static, corev1.EventTypeWarning, "invalid-source", "The deployment cannot be built: %s", err)
+		return nil
+	}
+
+	if isInline(static) {
+		// Roll the pods when the content of the ConfigMaps and Secrets changes
+		hash, err := r.inlineContentHash(ctx, static)
+		if err != nil {
+			return err
+		}
+		if expected.Spec.Template.Annotations == nil {
+			expected.Spec.Template.Annotations = map[string]string{}
+		}
+		expected.Spec.Template.Annotations[contentHashAnnotation] = hash
+	}
+
+	// Roll the pods when the configuration of nginx or its certificate changes
+	hash, err := r.nginxConfigHash(ctx, static)
+	if err != nil {
+		return err
+	}
+	if expected.Spec.Template.Annotations == nil {
+		expected.Spec.Template.Annotations = map[string]string{}
+	}
+	expected.Spec.Template.Annotations[configHashAnnotation] = hash
 
 	// Get the existing Deployment from cluster, if any
 	found := new(appsv1.Deployment)
-	err := r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
+	err = r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
 	if err == nil {
 		// Deployment exists in cluster
 
@@ -73,12 +101,79 @@ func (r *StaticReconciler) applyDeployment(ctx context.Context, log logr.Logger,
 	return nil
 }
 
-func (r *StaticReconciler) createDeployment(static *websitev1alpha1.Static) *appsv1.Deployment {
+func (r *StaticReconciler) createDeployment(static *websitev1beta1.Static) (*appsv1.Deployment, error) {
 	name := static.Name + "-deployment"
 	labels := map[string]string{
 		"app": name,
 	}
 	volumeName := "static-files"
+	mountPath := "/usr/share/nginx/html"
+
+	volume := corev1.Volume{
+		Name: volumeName,
+	}
+	credentialsVolumes := []corev1.Volume{}
+	initContainers := []corev1.Container{}
+	sidecars := []corev1.Container{}
+	if isInline(static) {
+		// The files are directly projected from the ConfigMaps and Secrets, without copy
+		volume.VolumeSource = createInlineVolumeSource(static)
+	} else if static.Spec.SharedVolume != nil {
+		// The files are read from the shared volume, populated beforehand
+		volume.VolumeSource = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: sharedClaimName(static),
+				ReadOnly:  true,
+			},
+		}
+	} else {
+		fetcher, err := r.createFetcher(static, volumeName)
+		if err != nil {
+			return nil, err
+		}
+		volume.VolumeSource = corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				SizeLimit: &static.Spec.DiskSize,
+			},
+		}
+		credentialsVolumes = createCredentialsVolumes(static)
+		if static.Spec.SyncInterval != nil {
+			// The assets are copied again periodically into a new release, and html links to the last one
+			initContainer, sidecar := createSyncContainers(static, fetcher)
+			initContainers = append(initContainers, *initContainer)
+			sidecars = append(sidecars, *sidecar)
+			mountPath = "/usr/share/nginx"
+		} else {
+			initContainers = append(initContainers, *fetcher)
+		}
+	}
+
+	ports := []corev1.ContainerPort{
+		{
+			ContainerPort: 80,
+		},
+	}
+	if servesHTTPS(static) {
+		// nginx is configured to serve HTTPS with the certificate
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: 443,
+		})
+	}
+	volumeMounts := append([]corev1.VolumeMount{
+		{
+			MountPath: mountPath,
+			Name:      volumeName,
+			ReadOnly:  true,
+		},
+	}, createNginxVolumeMounts(static)...)
+
+	// Roll the pods when the content of the source changes
+	var annotations map[string]string
+	if static.Status.ChangedContentHash != "" && !isInline(static) {
+		annotations = map[string]string{
+			contentHashAnnotation: static.Status.ChangedContentHash,
+		}
+	}
 
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -95,44 +190,16 @@ func (r *StaticReconciler) createDeployment(static *websitev1alpha1.Static) *app
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
-					Volumes: []corev1.Volume{
-						{
-							Name: volumeName,
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{
-									SizeLimit: &static.Spec.DiskSize,
-								},
-							},
-						},
-					},
-					InitContainers: []corev1.Container{
-						{
-							Name:  "copy-static-files",
-							Image: "gcr.io/cloud-builders/gcloud",
-							Command: []string{
-								"bash",
-								"-c",
-								"gsutil cp -R $(SOURCE)/* /mnt/",
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "SOURCE",
-									Value: static.Spec.Source,
-								},
-							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									MountPath: "/mnt",
-									Name:      volumeName,
-									ReadOnly:  false,
-								},
-							},
-						},
-					},
-					Containers: []corev1.Container{
+					ServiceAccountName: serviceAccountName(static),
+					Volumes: append(append([]corev1.Volume{
+						volume,
+					}, credentialsVolumes...), createNginxVolumes(static)...),
+					InitContainers: initContainers,
+					Containers: append([]corev1.Container{
 						{
 							Name:  "website",
 							Image: "nginx",
@@ -146,22 +213,12 @@ func (r *StaticReconciler) createDeployment(static *websitev1alpha1.Static) *app
 									"cpu":    *resource.NewMilliQuantity(r.Config.CpuLimitMilli, resource.DecimalSI),
 								},
 							},
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 80,
-								},
-							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									MountPath: "/usr/share/nginx/html",
-									Name:      volumeName,
-									ReadOnly:  true,
-								},
-							},
+							Ports:        ports,
+							VolumeMounts: volumeMounts,
 						},
-					},
+					}, sidecars...),
 				},
 			},
 		},
-	}
+	}, nil
 }
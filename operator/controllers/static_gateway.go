@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// httpRouteGroupKind is the group and kind of the HTTPRoutes of the Gateway API.
+// The Gateway API is not part of the Kubernetes API, HTTPRoutes are handled as unstructured objects
+var httpRouteGroupKind = schema.GroupKind{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"}
+
+// findGVK returns the preferred version of a kind served by the cluster,
+// or nil if the kind is not installed
+func findGVK(mapper meta.RESTMapper, groupKind schema.GroupKind) (*schema.GroupVersionKind, error) {
+	mapping, err := mapper.RESTMapping(groupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mapping.GroupVersionKind, nil
+}
+
+func (r *StaticReconciler) applyHTTPRoute(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	if r.httpRouteGVK == nil {
+		// The spec cannot be honored until the Gateway API is installed and the operator restarted
+		return r.clearRouteStatus(ctx, static)
+	}
+
+	name := static.Name + "-httproute"
+
+	// Get the existing HTTPRoute from cluster, if any
+	found := new(unstructured.Unstructured)
+	found.SetGroupVersionKind(*r.httpRouteGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: static.Namespace}, found)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			// Error trying to get
+			return err
+		}
+		found = nil
+	}
+
+	if static.Spec.Gateway == nil {
+		if err = r.clearRouteStatus(ctx, static); err != nil {
+			return err
+		}
+		if found == nil || !metav1.IsControlledBy(found, static) {
+			return nil
+		}
+
+		log.Info("HTTPRoute found but not expected => Delete HTTPRoute")
+
+		err = r.Delete(ctx, found)
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-httproute", "The HTTPRoute '%s.%s' has been deleted", found.GetNamespace(), found.GetName())
+		return nil
+	}
+
+	// Create in memory the HTTPRoute that is expected to exist into cluster
+	expected := r.createHTTPRoute(static)
+
+	if found != nil {
+		// HTTPRoute exists in cluster
+
+		if err = r.applyRouteStatus(ctx, log, static, found); err != nil {
+			return err
+		}
+
+		if !equality.Semantic.DeepDerivative(expected.Object["spec"], found.Object["spec"]) {
+
+			log.Info("HTTPRoute found but different than expected => Update HTTPRoute")
+
+			found.Object["spec"] = expected.Object["spec"]
+			controllerutil.SetControllerReference(static, found, r.Scheme)
+			err = r.Update(ctx, found)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-httproute", "The HTTPRoute '%s.%s' has been updated due to unexpected change", expected.GetNamespace(), expected.GetName())
+		}
+		return nil
+	}
+
+	log.Info("HTTPRoute not found => Create HTTPRoute")
+
+	// Set static as parent of HTTPRoute
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create HTTPRoute for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-httproute", "The HTTPRoute '%s.%s' has been created", expected.GetNamespace(), expected.GetName())
+
+	return nil
+}
+
+// clearRouteStatus reports in the RouteAccepted condition of the Static that the HTTPRoute cannot be created
+// when a Gateway is requested, with a warning when it changes; otherwise the condition is removed
+func (r *StaticReconciler) clearRouteStatus(ctx context.Context, static *websitev1beta1.Static) error {
+	const message = "The HTTPRoute cannot be created: the Gateway API is not installed"
+
+	if static.Spec.Gateway == nil {
+		if !removeCondition(static, websitev1beta1.RouteAccepted) {
+			return nil
+		}
+		return r.Status().Update(ctx, static)
+	}
+
+	if !setCondition(static, websitev1beta1.RouteAccepted, corev1.ConditionFalse, "GatewayAPIMissing", message) {
+		return nil
+	}
+	if err := r.Status().Update(ctx, static); err != nil {
+		return err
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeWarning, "gateway-api-missing", message)
+	return nil
+}
+
+// applyRouteStatus reports the acceptance of the HTTPRoute by the parent Gateway in the conditions of the Static
+func (r *StaticReconciler) applyRouteStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, route *unstructured.Unstructured) error {
+	gateway := static.Spec.Gateway
+
+	parents, _, _ := unstructured.NestedSlice(route.Object, "status", "parents")
+	for _, parent := range parents {
+		parent, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parent, "parentRef", "name")
+		namespace, _, _ := unstructured.NestedString(parent, "parentRef", "namespace")
+		if name != gateway.Name || (namespace != "" && namespace != gatewayNamespace(static)) {
+			continue
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+		for _, condition := range conditions {
+			condition, ok := condition.(map[string]interface{})
+			if !ok || condition["type"] != "Accepted" {
+				continue
+			}
+			status, _, _ := unstructured.NestedString(condition, "status")
+			reason, _, _ := unstructured.NestedString(condition, "reason")
+			message, _, _ := unstructured.NestedString(condition, "message")
+
+			if !setCondition(static, websitev1beta1.RouteAccepted, corev1.ConditionStatus(status), reason, message) {
+				return nil
+			}
+			log.Info(fmt.Sprintf("HTTPRoute accepted: %s (%s)", status, reason))
+			err := r.Status().Update(ctx, static)
+			if err != nil {
+				return err
+			}
+
+			if status != string(corev1.ConditionTrue) {
+				r.Recorder.Eventf(static, corev1.EventTypeWarning, "httproute-not-accepted", "The HTTPRoute has not been accepted by the gateway '%s.%s': %s", gatewayNamespace(static), gateway.Name, message)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// gatewayNamespace returns the namespace of the parent Gateway of the Static
+func gatewayNamespace(static *websitev1beta1.Static) string {
+	if static.Spec.Gateway.Namespace != "" {
+		return static.Spec.Gateway.Namespace
+	}
+	return static.Namespace
+}
+
+func (r *StaticReconciler) createHTTPRoute(static *websitev1beta1.Static) *unstructured.Unstructured {
+	gateway := static.Spec.Gateway
+
+	parentRef := map[string]interface{}{
+		"name": gateway.Name,
+	}
+	if gateway.Namespace != "" {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+
+	paths := gateway.Paths
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	matches := []interface{}{}
+	for _, path := range paths {
+		matches = append(matches, map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":  "PathPrefix",
+				"value": path,
+			},
+		})
+	}
+
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{
+			parentRef,
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": matches,
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": static.Name + "-service",
+						"port": int64(servicePort(static)),
+					},
+				},
+			},
+		},
+	}
+	if len(gateway.Hostnames) > 0 {
+		hostnames := []interface{}{}
+		for _, hostname := range gateway.Hostnames {
+			hostnames = append(hostnames, hostname)
+		}
+		spec["hostnames"] = hostnames
+	}
+
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	route.SetGroupVersionKind(*r.httpRouteGVK)
+	route.SetName(static.Name + "-httproute")
+	route.SetNamespace(static.Namespace)
+	return route
+}
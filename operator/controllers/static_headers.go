@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+)
+
+// securityHeaders are the presets of security headers, by name
+var securityHeaders = map[string]map[string]string{
+	"strict": {
+		"Strict-Transport-Security": "max-age=63072000; includeSubDomains",
+		"Content-Security-Policy":   "default-src 'self'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		"X-Frame-Options":           "DENY",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Permissions-Policy":        "camera=(), microphone=(), geolocation=()",
+	},
+}
+
+// nginxHeader is a response header rendered by nginxTemplate, as a map from the path to the value of the header.
+// An empty value is not sent by nginx
+type nginxHeader struct {
+	Name    string
+	Default string
+	Paths   []nginxHeaderPath
+	// Always sends the header with the error responses too, the other headers are only sent with
+	// the successful and redirect responses (so that an error is not cached as the asset it replaces)
+	Always bool
+}
+
+type nginxHeaderPath struct {
+	// Pattern is a regular expression prefixed by ~, as expected by the map of nginx
+	Pattern string
+	Value   string
+}
+
+// nginxHeaders returns the response headers of the Static, sorted by name
+func nginxHeaders(static *websitev1beta1.Static) []nginxHeader {
+	headers := map[string]*nginxHeader{}
+	header := func(name string) *nginxHeader {
+		// The names of the headers are case insensitive
+		name = http.CanonicalHeaderKey(name)
+		if headers[name] == nil {
+			headers[name] = &nginxHeader{Name: name}
+		}
+		return headers[name]
+	}
+
+	for name, value := range securityHeaders[static.Spec.SecurityHeaders] {
+		header(name).Default = value
+	}
+
+	for _, rule := range static.Spec.Headers {
+		pattern := "~" + rule.Path
+		if !rule.Regex {
+			pattern = "~^" + regexp.QuoteMeta(rule.Path)
+		}
+		names := []string{}
+		for name := range rule.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			h := header(name)
+			h.Paths = append(h.Paths, nginxHeaderPath{
+				Pattern: pattern,
+				Value:   rule.Values[name],
+			})
+		}
+	}
+
+	if caching := static.Spec.Caching; caching != nil {
+		// The rules are applied after the headers of the paths, which take precedence
+		for _, rule := range caching.Rules {
+			h := header("Cache-Control")
+			h.Paths = append(h.Paths, nginxHeaderPath{
+				Pattern: "~" + globToRegex(rule.Pattern),
+				Value:   cacheControl(rule),
+			})
+		}
+	}
+
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []nginxHeader{}
+	for _, name := range names {
+		headers[name].Always = isSecurityHeader(name)
+		result = append(result, *headers[name])
+	}
+	return result
+}
+
+// isSecurityHeader returns true if the header is part of a preset of security headers,
+// which protect the error pages too, whether it is set by the preset or by Headers
+func isSecurityHeader(name string) bool {
+	for _, preset := range securityHeaders {
+		if _, found := preset[name]; found {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,7 @@ package controllers
 import (
 	"context"
 
-	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 	"github.com/go-logr/logr"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -14,7 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-func (r *StaticReconciler) applyHPA(ctx context.Context, log logr.Logger, static *websitev1alpha1.Static) error {
+func (r *StaticReconciler) applyHPA(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
 
 	// Create in memory the HPA that is expected to exist into cluster
 	expected := r.createHPA(static)
@@ -60,7 +60,7 @@ func (r *StaticReconciler) applyHPA(ctx context.Context, log logr.Logger, static
 	return nil
 }
 
-func (r *StaticReconciler) createHPA(static *websitev1alpha1.Static) *autoscalingv1.HorizontalPodAutoscaler {
+func (r *StaticReconciler) createHPA(static *websitev1beta1.Static) *autoscalingv1.HorizontalPodAutoscaler {
 	name := static.Name + "-hpa"
 
 	return &autoscalingv1.HorizontalPodAutoscaler{
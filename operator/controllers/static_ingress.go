@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ingressClassAnnotation indicates the class of the Ingress controller serving an Ingress
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+func (r *StaticReconciler) applyIngress(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	name := static.Name + "-ingress"
+
+	// Get the existing Ingress from cluster, if any
+	found := new(networkingv1beta1.Ingress)
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: static.Namespace}, found)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			// Error trying to get
+			return err
+		}
+		found = nil
+	}
+
+	if static.Spec.Ingress == nil {
+		if found == nil || !metav1.IsControlledBy(found, static) {
+			return nil
+		}
+
+		log.Info("Ingress found but not expected => Delete ingress")
+
+		err = r.Delete(ctx, found)
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-ingress", "The ingress '%s.%s' has been deleted", found.Namespace, found.Name)
+		return r.applyIngressStatus(ctx, log, static, nil)
+	}
+
+	// Create in memory the Ingress that is expected to exist into cluster
+	expected := r.createIngress(static)
+
+	if found != nil {
+		// Ingress exists in cluster
+
+		if err = r.applyIngressStatus(ctx, log, static, found); err != nil {
+			return err
+		}
+
+		if !equality.Semantic.DeepDerivative(expected.Spec, found.Spec) || !equality.Semantic.DeepDerivative(expected.Annotations, found.Annotations) {
+
+			log.Info("Ingress found but different than expected => Update ingress")
+
+			found.Spec = expected.Spec
+			if found.Annotations == nil {
+				found.Annotations = map[string]string{}
+			}
+			for key, value := range expected.Annotations {
+				found.Annotations[key] = value
+			}
+			controllerutil.SetControllerReference(static, found, r.Scheme)
+			err = r.Update(ctx, found)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-ingress", "The ingress '%s.%s' has been updated due to unexpected change", expected.Namespace, expected.Name)
+		}
+		return nil
+	}
+
+	log.Info("Ingress not found => Create ingress")
+
+	// Set static as parent of ingress
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create ingress for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-ingress", "The ingress '%s.%s' has been created", expected.Namespace, expected.Name)
+
+	return nil
+}
+
+// applyIngressStatus reports the address and hostnames of the Ingress, if any, in the status of the Static
+func (r *StaticReconciler) applyIngressStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, ingress *networkingv1beta1.Ingress) error {
+	address := ""
+	var hosts []string
+	if ingress != nil {
+		if lb := ingress.Status.LoadBalancer.Ingress; len(lb) > 0 {
+			address = lb[0].IP
+			if address == "" {
+				address = lb[0].Hostname
+			}
+		}
+		hosts = static.Spec.Ingress.Hosts
+	}
+
+	if address == static.Status.IngressAddress && equality.Semantic.DeepEqual(hosts, static.Status.Hosts) {
+		return nil
+	}
+
+	changed := address != static.Status.IngressAddress
+	static.Status.IngressAddress = address
+	static.Status.Hosts = hosts
+	err := r.Status().Update(ctx, static)
+	if err != nil {
+		return err
+	}
+
+	if changed && address != "" {
+		log.Info(fmt.Sprintf("New ingress address: %s", address))
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-ingressaddress", "The ingress address has been updated to %s", address)
+	}
+	return nil
+}
+
+func (r *StaticReconciler) createIngress(static *websitev1beta1.Static) *networkingv1beta1.Ingress {
+	spec := static.Spec.Ingress
+
+	annotations := map[string]string{}
+	for key, value := range spec.Annotations {
+		annotations[key] = value
+	}
+	if spec.IngressClassName != "" {
+		annotations[ingressClassAnnotation] = spec.IngressClassName
+	}
+
+	paths := []networkingv1beta1.HTTPIngressPath{}
+	for _, path := range ingressPaths(static) {
+		paths = append(paths, networkingv1beta1.HTTPIngressPath{
+			Path: path,
+			Backend: networkingv1beta1.IngressBackend{
+				ServiceName: static.Name + "-service",
+				ServicePort: intstr.FromInt(int(servicePort(static))),
+			},
+		})
+	}
+
+	hosts := spec.Hosts
+	if len(hosts) == 0 {
+		// A rule without host serves all the hostnames
+		hosts = []string{""}
+	}
+	rules := []networkingv1beta1.IngressRule{}
+	for _, host := range hosts {
+		rules = append(rules, networkingv1beta1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1beta1.IngressRuleValue{
+				HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+					Paths: paths,
+				},
+			},
+		})
+	}
+
+	var tls []networkingv1beta1.IngressTLS
+	if static.Spec.TLS != nil && len(spec.Hosts) > 0 {
+		// The certificate is issued by cert-manager into this Secret
+		tls = []networkingv1beta1.IngressTLS{
+			{
+				Hosts:      spec.Hosts,
+				SecretName: tlsSecretName(static),
+			},
+		}
+	}
+
+	return &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:        static.Name + "-ingress",
+			Namespace:   static.Namespace,
+			Annotations: annotations,
+		},
+		Spec: networkingv1beta1.IngressSpec{
+			TLS:   tls,
+			Rules: rules,
+		},
+	}
+}
+
+// ingressPaths returns the paths served by the Ingress of the Static
+func ingressPaths(static *websitev1beta1.Static) []string {
+	if len(static.Spec.Ingress.Paths) == 0 {
+		return []string{"/"}
+	}
+	return static.Spec.Ingress.Paths
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// contentHashAnnotation is set on the pod template with a hash of the served content, so that pods are rolled when it changes
+const contentHashAnnotation = "website.example.com/content-hash"
+
+// isInline returns true if the assets of the Static are served from ConfigMaps and Secrets
+func isInline(static *websitev1beta1.Static) bool {
+	return static.Spec.Source.Type == websitev1beta1.SourceTypeInline
+}
+
+// createInlineVolumeSource projects the keys of the ConfigMaps and Secrets of the Static as files
+func createInlineVolumeSource(static *websitev1beta1.Static) corev1.VolumeSource {
+	sources := []corev1.VolumeProjection{}
+	for _, configMap := range static.Spec.Source.ConfigMaps {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: configMap,
+			},
+		})
+	}
+	for _, secret := range static.Spec.Source.Secrets {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: secret,
+			},
+		})
+	}
+	return corev1.VolumeSource{
+		Projected: &corev1.ProjectedVolumeSource{
+			Sources: sources,
+		},
+	}
+}
+
+// inlineContentHash returns a hash of the content of the ConfigMaps and Secrets of the Static.
+// Missing ConfigMaps and Secrets are ignored, the pods will wait for them to be created.
+func (r *StaticReconciler) inlineContentHash(ctx context.Context, static *websitev1beta1.Static) (string, error) {
+	h := sha256.New()
+
+	for _, ref := range static.Spec.Source.ConfigMaps {
+		configMap := new(corev1.ConfigMap)
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: static.Namespace}, configMap)
+		if err != nil {
+			if err = client.IgnoreNotFound(err); err != nil {
+				return "", err
+			}
+			continue
+		}
+		data := map[string][]byte{}
+		for key, value := range configMap.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range configMap.BinaryData {
+			data[key] = value
+		}
+		writeHash(h, "configmap/"+ref.Name, data)
+	}
+
+	for _, ref := range static.Spec.Source.Secrets {
+		secret := new(corev1.Secret)
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: static.Namespace}, secret)
+		if err != nil {
+			if err = client.IgnoreNotFound(err); err != nil {
+				return "", err
+			}
+			continue
+		}
+		writeHash(h, "secret/"+ref.Name, secret.Data)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// writeHash writes the keys and values of data into hash, in a stable order
+func writeHash(h hash.Hash, prefix string, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s/%s=%d:", prefix, key, len(data[key]))
+		h.Write(data[key])
+	}
+}
+
+// configMapToStatics maps a ConfigMap to requests for the Statics serving it
+func (r *StaticReconciler) configMapToStatics(configMap handler.MapObject) []reconcile.Request {
+	return r.inlineToStatics(configMap, func(static *websitev1beta1.Static) []corev1.LocalObjectReference {
+		return static.Spec.Source.ConfigMaps
+	})
+}
+
+// secretToStatics maps a Secret to requests for the Statics serving it, as content or as certificate
+func (r *StaticReconciler) secretToStatics(secret handler.MapObject) []reconcile.Request {
+	return r.inlineToStatics(secret, func(static *websitev1beta1.Static) []corev1.LocalObjectReference {
+		if servesHTTPS(static) {
+			return append([]corev1.LocalObjectReference{{Name: httpsSecretName(static)}}, static.Spec.Source.Secrets...)
+		}
+		return static.Spec.Source.Secrets
+	})
+}
+
+func (r *StaticReconciler) inlineToStatics(object handler.MapObject, refs func(*websitev1beta1.Static) []corev1.LocalObjectReference) []reconcile.Request {
+	statics := new(websitev1beta1.StaticList)
+	if err := r.List(context.Background(), statics, client.InNamespace(object.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list statics", "namespace", object.Meta.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range statics.Items {
+		for _, ref := range refs(&statics.Items[i]) {
+			if ref.Name == object.Meta.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      statics.Items[i].Name,
+						Namespace: statics.Items[i].Namespace,
+					},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,485 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// configHashAnnotation is set on the pod template with a hash of the configuration of nginx and its certificate,
+// so that pods are rolled when they change
+const configHashAnnotation = "website.example.com/config-hash"
+
+const (
+	// nginxConfigVolumeName is the name of the volume containing the configuration of nginx
+	nginxConfigVolumeName = "nginx-config"
+
+	// nginxTLSVolumeName is the name of the volume containing the certificate served by nginx
+	nginxTLSVolumeName = "nginx-tls"
+
+	// nginxTLSPath is where the certificate served by nginx is mounted
+	nginxTLSPath = "/etc/nginx/tls"
+)
+
+// nginxTemplate renders the configuration of nginx, replacing the configuration of the image
+var nginxTemplate = template.Must(template.New("nginx.conf").Funcs(template.FuncMap{
+	"quote":    nginxQuote,
+	"quoteAll": nginxQuoteAll,
+}).Parse(`user  nginx;
+worker_processes  auto;
+
+error_log  /var/log/nginx/error.log notice;
+pid        /var/run/nginx.pid;
+
+events {
+    worker_connections  1024;
+}
+
+http {
+    include       /etc/nginx/mime.types;
+    default_type  application/octet-stream;
+{{- if .MimeTypes }}
+
+    types {
+{{- range .MimeTypes }}
+        {{ quote .Type }}  {{ quote .Extension }};
+{{- end }}
+    }
+{{- end }}
+
+    log_format  main  '$remote_addr - $remote_user [$time_local] "$request" '
+                      '$status $body_bytes_sent "$http_referer" '
+                      '"$http_user_agent" "$http_x_forwarded_for"';
+
+    access_log  /var/log/nginx/access.log  main;
+
+    sendfile           on;
+    keepalive_timeout  65;
+{{- with .Gzip }}
+
+    gzip             on;
+    gzip_min_length  {{ .MinLength }};
+    gzip_types       {{ quoteAll .Types }};
+{{- end }}
+{{- range $i, $header := .Headers }}
+
+    map $uri $static_header_{{ $i }} {
+        default  {{ quote .Default }};
+{{- range .Paths }}
+        {{ quote .Pattern }}  {{ quote .Value }};
+{{- end }}
+    }
+{{- end }}
+
+    server {
+        listen       80;
+        server_name  _;
+{{- if .RedirectHTTP }}
+
+        return 301 https://$host{{ .RedirectPort }}$request_uri;
+{{- else }}
+{{ template "site" . }}
+{{- end }}
+    }
+{{- if .HTTPS }}
+
+    server {
+        listen       443 ssl;
+        server_name  _;
+
+        ssl_certificate      {{ .TLSPath }}/tls.crt;
+        ssl_certificate_key  {{ .TLSPath }}/tls.key;
+{{ template "site" . }}
+    }
+{{- end }}
+}
+{{- define "site" }}
+        root   /usr/share/nginx/html;
+        index  {{ quoteAll .IndexFiles }};
+{{- if .Headers }}
+{{ range $i, $header := .Headers }}
+        add_header  {{ quote .Name }} $static_header_{{ $i }}{{ if .Always }} always{{ end }};
+{{- end }}
+{{- end }}
+{{- if not .ETag }}
+
+        etag  off;
+{{- end }}
+{{- if not .LastModified }}
+
+        # An empty value removes the header
+        if_modified_since  off;
+        add_header         Last-Modified "";
+{{- end }}
+{{- range .Redirects }}
+
+        location {{ if .Regex }}~{{ else }}={{ end }} {{ quote .From }} {
+            return  {{ .Status }} {{ quote .To }};
+        }
+{{- end }}
+{{- if .ImportRedirects }}
+
+        location = /_redirects {
+            return  404;
+        }
+
+        include  {{ .RedirectsPath }};
+{{- end }}
+
+        location / {
+{{- if .TryFiles }}
+            try_files  {{ quoteAll .TryFiles }};
+{{- end }}
+        }
+{{- if .SPA }}
+
+        # The paths with a file extension are assets, not routes of the application
+        location ~ \.[^/]+$ {
+            try_files  $uri =404;
+        }
+{{- end }}
+{{- range .ErrorPages }}
+
+        error_page  {{ .Codes }}  {{ quote .Page }};
+{{- end }}
+{{- end }}
+`))
+
+// nginxConfig is the data rendered by nginxTemplate
+type nginxConfig struct {
+	HTTPS           bool
+	RedirectHTTP    bool
+	RedirectPort    string
+	TLSPath         string
+	IndexFiles      []string
+	TryFiles        []string
+	SPA             bool
+	Redirects       []nginxRedirect
+	ImportRedirects bool
+	RedirectsPath   string
+	Headers         []nginxHeader
+	ETag            bool
+	LastModified    bool
+	Gzip            *nginxGzip
+	ErrorPages      []nginxErrorPage
+	MimeTypes       []nginxMimeType
+}
+
+type nginxGzip struct {
+	MinLength int32
+	Types     []string
+}
+
+type nginxErrorPage struct {
+	Codes string
+	Page  string
+}
+
+type nginxMimeType struct {
+	Extension string
+	Type      string
+}
+
+// newNginxConfig returns the configuration of nginx for the Static
+func newNginxConfig(static *websitev1beta1.Static) nginxConfig {
+	config := nginxConfig{
+		IndexFiles: []string{"index.html", "index.htm"},
+		ErrorPages: []nginxErrorPage{
+			{
+				Codes: "500 502 503 504",
+				Page:  "/50x.html",
+			},
+		},
+	}
+
+	if servesHTTPS(static) {
+		config.HTTPS = true
+		config.RedirectHTTP = static.Spec.HTTPS.RedirectHTTP
+		config.TLSPath = nginxTLSPath
+		if port := httpsPort(static); port != 443 {
+			config.RedirectPort = fmt.Sprintf(":%d", port)
+		}
+	}
+
+	serving := static.Spec.Serving
+	if serving == nil {
+		serving = &websitev1beta1.Serving{}
+	}
+
+	if len(serving.IndexFiles) > 0 {
+		config.IndexFiles = serving.IndexFiles
+	}
+	config.TryFiles = serving.TryFiles
+
+	if gzip := serving.Gzip; gzip != nil {
+		config.Gzip = &nginxGzip{
+			MinLength: 1024,
+			Types:     []string{"text/css", "text/plain", "application/javascript", "application/json", "application/xml", "image/svg+xml"},
+		}
+		if gzip.MinLength != nil {
+			config.Gzip.MinLength = *gzip.MinLength
+		}
+		if len(gzip.Types) > 0 {
+			config.Gzip.Types = gzip.Types
+		}
+	}
+
+	if len(serving.ErrorPages) > 0 {
+		config.ErrorPages = nil
+		for _, page := range serving.ErrorPages {
+			codes := []string{}
+			for _, code := range page.Codes {
+				codes = append(codes, strconv.Itoa(int(code)))
+			}
+			config.ErrorPages = append(config.ErrorPages, nginxErrorPage{
+				Codes: strings.Join(codes, " "),
+				Page:  page.Page,
+			})
+		}
+	}
+
+	// The types are declared after the types of the image, and take precedence over them
+	extensions := []string{}
+	for extension := range serving.MimeTypes {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	for _, extension := range extensions {
+		config.MimeTypes = append(config.MimeTypes, nginxMimeType{
+			Extension: strings.TrimPrefix(extension, "."),
+			Type:      serving.MimeTypes[extension],
+		})
+	}
+
+	config.Redirects = nginxRedirects(static)
+	config.Headers = nginxHeaders(static)
+	config.ETag = sendsETag(static)
+	config.LastModified = sendsLastModified(static)
+	if static.Spec.ImportRedirects {
+		config.ImportRedirects = true
+		config.RedirectsPath = redirectsPath
+	}
+
+	if static.Spec.SPA {
+		// The routes of the application fall back to the index file, with a 200 status
+		config.SPA = true
+		config.TryFiles = []string{"$uri", "$uri/", "/" + strings.TrimPrefix(config.IndexFiles[0], "/")}
+	}
+
+	return config
+}
+
+// servesHTTPS returns true if nginx serves HTTPS for the Static
+func servesHTTPS(static *websitev1beta1.Static) bool {
+	return static.Spec.HTTPS != nil
+}
+
+// httpsSecretName returns the name of the Secret containing the certificate served by nginx
+func httpsSecretName(static *websitev1beta1.Static) string {
+	if static.Spec.HTTPS.SecretName != "" {
+		return static.Spec.HTTPS.SecretName
+	}
+	return tlsSecretName(static)
+}
+
+// httpsPort returns the HTTPS port exposed by the Service of the Static
+func httpsPort(static *websitev1beta1.Static) int32 {
+	if static.Spec.HTTPS.Port != 0 {
+		return static.Spec.HTTPS.Port
+	}
+	return 443
+}
+
+// nginxConfigMapName returns the name of the ConfigMap containing the configuration of nginx
+func nginxConfigMapName(static *websitev1beta1.Static) string {
+	return static.Name + "-nginx"
+}
+
+// renderNginxConfig renders the configuration of nginx for the Static
+func renderNginxConfig(static *websitev1beta1.Static) (string, error) {
+	var config bytes.Buffer
+	err := nginxTemplate.Execute(&config, newNginxConfig(static))
+	return config.String(), err
+}
+
+func (r *StaticReconciler) applyNginxConfigMap(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	// Get the existing ConfigMap from cluster, if any
+	found := new(corev1.ConfigMap)
+	err := r.Get(ctx, types.NamespacedName{Name: nginxConfigMapName(static), Namespace: static.Namespace}, found)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			// Error trying to get
+			return err
+		}
+		found = nil
+	}
+
+	// Create in memory the ConfigMap that is expected to exist into cluster
+	expected, err := r.createNginxConfigMap(static)
+	if err != nil {
+		return err
+	}
+
+	if found != nil {
+		// ConfigMap exists in cluster
+
+		if !equality.Semantic.DeepEqual(expected.Data, found.Data) {
+
+			log.Info("ConfigMap found but different than expected => Update config map")
+
+			found.Data = expected.Data
+			controllerutil.SetControllerReference(static, found, r.Scheme)
+			err = r.Update(ctx, found)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-configmap", "The config map '%s.%s' has been updated due to unexpected change", expected.Namespace, expected.Name)
+		}
+		return nil
+	}
+
+	log.Info("ConfigMap not found => Create config map")
+
+	// Set static as parent of config map
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create config map for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-configmap", "The config map '%s.%s' has been created", expected.Namespace, expected.Name)
+
+	return nil
+}
+
+func (r *StaticReconciler) createNginxConfigMap(static *websitev1beta1.Static) (*corev1.ConfigMap, error) {
+	config, err := renderNginxConfig(static)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{
+		"nginx.conf": config,
+	}
+	if static.Spec.ImportRedirects {
+		data[redirectsScriptName] = redirectsScript
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:      nginxConfigMapName(static),
+			Namespace: static.Namespace,
+		},
+		Data: data,
+	}, nil
+}
+
+// nginxConfigHash returns a hash of the configuration of nginx and of the certificate it serves.
+// nginx reads them only at startup, the pods need to be restarted when they change
+func (r *StaticReconciler) nginxConfigHash(ctx context.Context, static *websitev1beta1.Static) (string, error) {
+	h := sha256.New()
+
+	config, err := renderNginxConfig(static)
+	if err != nil {
+		return "", err
+	}
+	h.Write([]byte(config))
+
+	if !servesHTTPS(static) {
+		return fmt.Sprintf("%x", h.Sum(nil)), nil
+	}
+
+	secret := new(corev1.Secret)
+	err = r.Get(ctx, types.NamespacedName{Name: httpsSecretName(static), Namespace: static.Namespace}, secret)
+	if err != nil {
+		// The pods wait for a missing Secret to be created
+		if err = client.IgnoreNotFound(err); err != nil {
+			return "", err
+		}
+	}
+	writeHash(h, "secret/"+secret.Name, secret.Data)
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// createNginxVolumes returns the volumes containing the configuration of nginx and the certificate it serves, if any
+func createNginxVolumes(static *websitev1beta1.Static) []corev1.Volume {
+	volumes := []corev1.Volume{
+		{
+			Name: nginxConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: nginxConfigMapName(static),
+					},
+				},
+			},
+		},
+	}
+	if static.Spec.ImportRedirects {
+		// The entrypoint of the nginx image runs only the executable scripts
+		mode := int32(0555)
+		volumes[0].ConfigMap.DefaultMode = &mode
+	}
+	if servesHTTPS(static) {
+		// Private keys must not be readable by others
+		mode := int32(0400)
+		volumes = append(volumes, corev1.Volume{
+			Name: nginxTLSVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  httpsSecretName(static),
+					DefaultMode: &mode,
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+// createNginxVolumeMounts returns the mounts of the configuration of nginx and the certificate it serves, if any
+func createNginxVolumeMounts(static *websitev1beta1.Static) []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{
+		{
+			// The pods are rolled when the configuration changes, the file does not need to be updated
+			MountPath: "/etc/nginx/nginx.conf",
+			SubPath:   "nginx.conf",
+			Name:      nginxConfigVolumeName,
+			ReadOnly:  true,
+		},
+	}
+	if static.Spec.ImportRedirects {
+		mounts = append(mounts, corev1.VolumeMount{
+			MountPath: "/docker-entrypoint.d/" + redirectsScriptName,
+			SubPath:   redirectsScriptName,
+			Name:      nginxConfigVolumeName,
+			ReadOnly:  true,
+		})
+	}
+	if servesHTTPS(static) {
+		mounts = append(mounts, corev1.VolumeMount{
+			MountPath: nginxTLSPath,
+			Name:      nginxTLSVolumeName,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"strings"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+)
+
+const (
+	// redirectsPath is where nginx reads the redirects imported from the _redirects file of the assets
+	redirectsPath = "/etc/nginx/redirects.conf"
+
+	// redirectsScriptName is the name of the script importing the _redirects file, run by the entrypoint of the nginx image
+	redirectsScriptName = "40-import-redirects.sh"
+)
+
+// redirectsScript converts at startup the _redirects file of the assets, in the format of Netlify, into nginx locations.
+// The file is part of the content, which is not accessible to the operator.
+// The paths are matched with regular expressions, so that the redirects of the spec take precedence
+// and duplicate paths do not prevent nginx from starting
+const redirectsScript = `#!/bin/sh
+set -e
+: > ` + redirectsPath + `
+[ -f /usr/share/nginx/html/_redirects ] || exit 0
+awk '
+function quote(s) { gsub(/[\\"]/, "\\\\&", s); return "\"" s "\"" }
+/^[ \t]*(#|$)/ { next }
+{
+    from = $1; to = $2; status = $3
+    sub(/!$/, "", status)
+    if (status == "") status = 301
+    # Rewrites, placeholders and conditions are not supported
+    if (NF < 2 || NF > 3 || status !~ /^30[1278]$/ || from ~ /:/) next
+    splat = from ~ /\/\*$/
+    if (splat) sub(/\*$/, "", from)
+    gsub(/[][\\.+?(){}|^$*]/, "\\\\&", from)
+    if (splat) { from = from "(.*)"; gsub(/:splat/, "$1", to) }
+    printf "location ~ %s {\n    return  %s %s;\n}\n", quote("^" from "$"), status, quote(to)
+}' /usr/share/nginx/html/_redirects > ` + redirectsPath + `
+`
+
+// nginxRedirect is a redirect rendered by nginxTemplate
+type nginxRedirect struct {
+	Regex  bool
+	From   string
+	To     string
+	Status int32
+}
+
+// nginxRedirects returns the redirects declared in the spec of the Static
+func nginxRedirects(static *websitev1beta1.Static) []nginxRedirect {
+	redirects := []nginxRedirect{}
+	for _, redirect := range static.Spec.Redirects {
+		status := redirect.Status
+		if status == 0 {
+			status = 301
+		}
+		redirects = append(redirects, nginxRedirect{
+			Regex:  redirect.Regex,
+			From:   redirect.From,
+			To:     redirect.To,
+			Status: status,
+		})
+	}
+	return redirects
+}
+
+// nginxQuote quotes a value in the configuration of nginx, so that it is not interpreted as directives
+func nginxQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// nginxQuoteAll returns the values as quoted strings of the configuration of nginx, separated by spaces
+func nginxQuoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = nginxQuote(value)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -3,8 +3,10 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
-	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -15,7 +17,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-func (r *StaticReconciler) applyService(ctx context.Context, log logr.Logger, static *websitev1alpha1.Static) error {
+// managedAnnotationsAnnotation lists the annotations of an object set by the operator,
+// so that they are removed when they are removed from the spec of the Static
+const managedAnnotationsAnnotation = "website.example.com/managed-annotations"
+
+func (r *StaticReconciler) applyService(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
 
 	// Create in memory the Service that is expected to exist into cluster
 	expected := r.createService(static)
@@ -27,19 +33,24 @@ func (r *StaticReconciler) applyService(ctx context.Context, log logr.Logger, st
 		// Service exists in cluster
 
 		// Check status
-		if len(found.Status.LoadBalancer.Ingress) > 0 && found.Status.LoadBalancer.Ingress[0].IP != static.Status.ExternalIP {
-			log.Info(fmt.Sprintf("New external IP: %s", found.Status.LoadBalancer.Ingress[0].IP))
-			static.Status.ExternalIP = found.Status.LoadBalancer.Ingress[0].IP
-			err = r.Status().Update(ctx, static)
-			if err != nil {
-				return err
-			}
-
-			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-externalip", "The external IP has been updated to %s", static.Status.ExternalIP)
+		if err = r.applyServiceStatus(ctx, log, static, found); err != nil {
+			return err
 		}
 
-		expected.Spec.Ports[0].NodePort = found.Spec.Ports[0].NodePort // Do not check nodeport
-		if !equality.Semantic.DeepDerivative(expected.Spec, found.Spec) {
+		if expected.Spec.Type != corev1.ServiceTypeClusterIP {
+			for i := range expected.Spec.Ports {
+				if i < len(found.Spec.Ports) {
+					expected.Spec.Ports[i].NodePort = found.Spec.Ports[i].NodePort // Do not check nodeport
+				}
+			}
+		}
+		if expected.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+			expected.Spec.HealthCheckNodePort = found.Spec.HealthCheckNodePort // Do not check health check nodeport
+		}
+		// The source ranges removed from the spec and the annotations set previously are not detected as derivative changes
+		rangesChanged := !equality.Semantic.DeepEqual(expected.Spec.LoadBalancerSourceRanges, found.Spec.LoadBalancerSourceRanges)
+		annotationsChanged := expected.Annotations[managedAnnotationsAnnotation] != found.Annotations[managedAnnotationsAnnotation]
+		if !equality.Semantic.DeepDerivative(expected.Spec, found.Spec) || !equality.Semantic.DeepDerivative(expected.Annotations, found.Annotations) || rangesChanged || annotationsChanged {
 
 			log.Info("Service found but different than expected => Update service")
 
@@ -75,7 +86,7 @@ func (r *StaticReconciler) applyService(ctx context.Context, log logr.Logger, st
 	return nil
 }
 
-func (r *StaticReconciler) createService(static *websitev1alpha1.Static) *corev1.Service {
+func (r *StaticReconciler) createService(static *websitev1beta1.Static) *corev1.Service {
 	name := static.Name + "-service"
 
 	result := &corev1.Service{
@@ -95,14 +106,148 @@ func (r *StaticReconciler) createService(static *websitev1alpha1.Static) *corev1
 	return result
 }
 
-func (r *StaticReconciler) updateService(static *websitev1alpha1.Static, service *corev1.Service) {
-	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+// updateService sets the fields of the Service chosen by the spec of the Static.
+// The source ranges and the external traffic policy removed from the spec are reset to their defaults,
+// and the annotations previously set by the operator are removed
+func (r *StaticReconciler) updateService(static *websitev1beta1.Static, service *corev1.Service) {
+	service.Spec.Type = serviceType(static)
 	service.Spec.Selector = map[string]string{
 		"app": static.Name + "-deployment",
 	}
-	if len(service.Spec.Ports) > 0 {
-		service.Spec.Ports[0].Port = 80
-		service.Spec.Ports[0].TargetPort = intstr.FromInt(80)
-		service.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	service.Spec.LoadBalancerSourceRanges = nil
+	service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	var annotations map[string]string
+	if spec := static.Spec.Service; spec != nil {
+		service.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
+		if spec.ExternalTrafficPolicy != "" {
+			service.Spec.ExternalTrafficPolicy = spec.ExternalTrafficPolicy
+		}
+		annotations = spec.Annotations
+	}
+	updateManagedAnnotations(&service.ObjectMeta, annotations)
+
+	// Remove the fields not allowed for the type of the Service
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		service.Spec.LoadBalancerSourceRanges = nil
+	}
+	if service.Spec.Type == corev1.ServiceTypeClusterIP {
+		service.Spec.ExternalTrafficPolicy = ""
+	}
+	if service.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		service.Spec.HealthCheckNodePort = 0
+	}
+
+	// The existing ports are updated in place, to keep their node ports
+	count := 1
+	if servesHTTPS(static) {
+		count = 2
+	}
+	for len(service.Spec.Ports) < count {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{})
+	}
+	service.Spec.Ports = service.Spec.Ports[:count]
+
+	service.Spec.Ports[0].Name = ""
+	service.Spec.Ports[0].Port = servicePort(static)
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(80)
+	service.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	if servesHTTPS(static) {
+		// The ports of a Service exposing several ports must be named
+		service.Spec.Ports[0].Name = "http"
+		service.Spec.Ports[1].Name = "https"
+		service.Spec.Ports[1].Port = httpsPort(static)
+		service.Spec.Ports[1].TargetPort = intstr.FromInt(443)
+		service.Spec.Ports[1].Protocol = corev1.ProtocolTCP
+	}
+	if service.Spec.Type == corev1.ServiceTypeClusterIP {
+		// A node port is not allowed for a ClusterIP service
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+	}
+}
+
+// updateManagedAnnotations sets the annotations on an object, and removes the ones set previously by the operator
+// and no longer expected. The annotations set by other controllers are kept
+func updateManagedAnnotations(object *metav1.ObjectMeta, annotations map[string]string) {
+	if previous := object.Annotations[managedAnnotationsAnnotation]; previous != "" {
+		for _, key := range strings.Split(previous, ",") {
+			if _, ok := annotations[key]; !ok {
+				delete(object.Annotations, key)
+			}
+		}
+		delete(object.Annotations, managedAnnotationsAnnotation)
+	}
+	if len(annotations) == 0 {
+		return
+	}
+
+	if object.Annotations == nil {
+		object.Annotations = map[string]string{}
+	}
+	keys := make([]string, 0, len(annotations))
+	for key, value := range annotations {
+		object.Annotations[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	object.Annotations[managedAnnotationsAnnotation] = strings.Join(keys, ",")
+}
+
+// applyServiceStatus reports the addresses of the load balancer of the Service in the status of the Static
+func (r *StaticReconciler) applyServiceStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, service *corev1.Service) error {
+	var ips, hostnames []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips = append(ips, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			hostnames = append(hostnames, ingress.Hostname)
+		}
+	}
+	addresses := append(ips, hostnames...)
+
+	// Some load balancers, e.g. on AWS, have a hostname and no IP
+	externalIP := ""
+	if len(addresses) > 0 {
+		externalIP = addresses[0]
+	}
+
+	if externalIP == static.Status.ExternalIP && equality.Semantic.DeepEqual(addresses, static.Status.LoadBalancerAddresses) {
+		return nil
+	}
+
+	changed := externalIP != static.Status.ExternalIP
+	static.Status.ExternalIP = externalIP
+	static.Status.LoadBalancerAddresses = addresses
+	err := r.Status().Update(ctx, static)
+	if err != nil {
+		return err
+	}
+
+	if changed && externalIP != "" {
+		log.Info(fmt.Sprintf("New external IP: %s", externalIP))
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-externalip", "The external IP has been updated to %s", externalIP)
+	}
+	return nil
+}
+
+// serviceType returns the type of the Service exposing the Static
+func serviceType(static *websitev1beta1.Static) corev1.ServiceType {
+	if static.Spec.Service != nil && static.Spec.Service.Type != "" {
+		return static.Spec.Service.Type
+	}
+	if static.Spec.Ingress != nil || static.Spec.Gateway != nil {
+		// The Static is exposed by the Ingress or the Gateway
+		return corev1.ServiceTypeClusterIP
+	}
+	return corev1.ServiceTypeLoadBalancer
+}
+
+// servicePort returns the port exposed by the Service of the Static
+func servicePort(static *websitev1beta1.Static) int32 {
+	if static.Spec.Service != nil && static.Spec.Service.Port != 0 {
+		return static.Spec.Service.Port
 	}
+	return 80
 }
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// serviceAccountName returns the name of the ServiceAccount of the instances of the Static,
+// or an empty string to use the default ServiceAccount of the namespace
+func serviceAccountName(static *websitev1beta1.Static) string {
+	if static.Spec.ServiceAccount == nil {
+		return ""
+	}
+	if static.Spec.ServiceAccount.Name != "" {
+		return static.Spec.ServiceAccount.Name
+	}
+	return static.Name + "-serviceaccount"
+}
+
+func (r *StaticReconciler) applyServiceAccount(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	if static.Spec.ServiceAccount == nil || static.Spec.ServiceAccount.Name != "" {
+		// No ServiceAccount to create
+		return nil
+	}
+
+	// Create in memory the ServiceAccount that is expected to exist into cluster
+	expected := r.createServiceAccount(static)
+
+	// Get the existing ServiceAccount from cluster, if any
+	found := new(corev1.ServiceAccount)
+	err := r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
+	if err == nil {
+		// ServiceAccount exists in cluster
+
+		if !equality.Semantic.DeepDerivative(expected.Annotations, found.Annotations) ||
+			expected.Annotations[managedAnnotationsAnnotation] != found.Annotations[managedAnnotationsAnnotation] {
+
+			log.Info("ServiceAccount found but different than expected => Update service account")
+
+			updateManagedAnnotations(&found.ObjectMeta, static.Spec.ServiceAccount.Annotations)
+			err = r.Update(ctx, found)
+			if err != nil {
+				return err
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "update-serviceaccount", "The service account '%s.%s' has been updated due to unexpected change", expected.Namespace, expected.Name)
+		}
+		return nil
+	}
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		// Error trying to get
+		return err
+	}
+
+	log.Info("ServiceAccount not found => Create service account")
+
+	// Set static as parent of service account
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create service account for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-serviceaccount", "The service account '%s.%s' has been created", expected.Namespace, expected.Name)
+
+	return nil
+}
+
+func (r *StaticReconciler) createServiceAccount(static *websitev1beta1.Static) *corev1.ServiceAccount {
+	result := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:      serviceAccountName(static),
+			Namespace: static.Namespace,
+		},
+	}
+	updateManagedAnnotations(&result.ObjectMeta, static.Spec.ServiceAccount.Annotations)
+	return result
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fetcherContainerName is the name of the container copying the assets into the pods
+const fetcherContainerName = "copy-static-files"
+
+// fingerprinterContainerName is the name of the container checking the content of the source for changes
+const fingerprinterContainerName = "check-static-files"
+
+const (
+	// credentialsVolumeName is the name of the volume containing the credentials of the source
+	credentialsVolumeName = "credentials"
+
+	// credentialsPath is where the credentials of the source are mounted into the fetcher
+	credentialsPath = "/etc/static-credentials"
+)
+
+// fetcher returns the container copying the assets from the source of a Static into the $DEST directory.
+// $DEST is a shell variable, and not a Kubernetes variable, so that the script can be run into another directory.
+// The values of the spec are passed in quoted shell variables too, so that the shell does not split or interpret them
+// (e.g. the `&` of a query string).
+// With fingerprint, the container writes instead a fingerprint of the content of the source as termination message
+// (object generations, ETag, commit or digest), changing when the content changes
+type fetcher func(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error)
+
+// fetchers associates the type of a source to the fetcher able to copy its assets
+var fetchers = map[websitev1beta1.SourceType]fetcher{
+	websitev1beta1.SourceTypeGCS:  gcsFetcher,
+	websitev1beta1.SourceTypeS3:   s3Fetcher,
+	websitev1beta1.SourceTypeGit:  gitFetcher,
+	websitev1beta1.SourceTypeOCI:  ociFetcher,
+	websitev1beta1.SourceTypeHTTP: httpFetcher,
+}
+
+// checksumMismatch starts the termination message of a fetcher failing to verify the checksum of the source
+const checksumMismatch = "checksum mismatch"
+
+func (r *StaticReconciler) createFetcher(static *websitev1beta1.Static, volumeName string) (*corev1.Container, error) {
+	container, err := r.createSourceContainer(static, false)
+	if err != nil {
+		return nil, err
+	}
+	container.Name = fetcherContainerName
+	container.Env = append([]corev1.EnvVar{
+		{
+			Name:  "DEST",
+			Value: "/mnt",
+		},
+	}, container.Env...)
+	// Report the output of a failing fetcher in the status of its pod
+	container.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	container.VolumeMounts = append([]corev1.VolumeMount{
+		{
+			MountPath: "/mnt",
+			Name:      volumeName,
+			ReadOnly:  false,
+		},
+	}, container.VolumeMounts...)
+	return container, nil
+}
+
+// createFingerprinter returns the container writing a fingerprint of the content of the source as termination message
+func (r *StaticReconciler) createFingerprinter(static *websitev1beta1.Static) (*corev1.Container, error) {
+	container, err := r.createSourceContainer(static, true)
+	if err != nil {
+		return nil, err
+	}
+	container.Name = fingerprinterContainerName
+	return container, nil
+}
+
+// createSourceContainer returns the container accessing the source of the Static, with its credentials if any
+func (r *StaticReconciler) createSourceContainer(static *websitev1beta1.Static, fingerprint bool) (*corev1.Container, error) {
+	fetch, ok := fetchers[static.Spec.Source.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %q for source %q", static.Spec.Source.Type, static.Spec.Source.URL)
+	}
+
+	container, err := fetch(r, static, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if static.Spec.Source.Credentials != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			MountPath: credentialsPath,
+			Name:      credentialsVolumeName,
+			ReadOnly:  true,
+		})
+	}
+	return &container, nil
+}
+
+// createCredentialsVolumes returns the volumes to add to the pods running the fetcher of the Static
+func createCredentialsVolumes(static *websitev1beta1.Static) []corev1.Volume {
+	if static.Spec.Source.Credentials == nil {
+		return nil
+	}
+
+	// Private keys must not be readable by others
+	mode := int32(0400)
+	return []corev1.Volume{
+		{
+			Name: credentialsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  static.Spec.Source.Credentials.Name,
+					DefaultMode: &mode,
+				},
+			},
+		},
+	}
+}
+
+func gcsFetcher(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error) {
+	command := "gsutil cp -R \"$SOURCE/*\" $DEST/"
+	if fingerprint {
+		// The listing contains the generation of each object
+		command = "set -o pipefail && gsutil ls -a -r \"$SOURCE/**\" | sha256sum | cut -d ' ' -f 1 > /dev/termination-log"
+	}
+	if static.Spec.Source.Credentials != nil {
+		command = "gcloud auth activate-service-account --key-file=" + credentialsPath + "/key.json && " + command
+	}
+
+	return corev1.Container{
+		Image: r.Config.GcsFetcherImage,
+		Command: []string{
+			"bash",
+			"-c",
+			command,
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "SOURCE",
+				Value: static.Spec.Source.URL,
+			},
+		},
+	}, nil
+}
+
+func s3Fetcher(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error) {
+	env := []corev1.EnvVar{
+		{
+			Name:  "SOURCE",
+			Value: static.Spec.Source.URL,
+		},
+	}
+	envFrom := []corev1.EnvFromSource{}
+	commands := []string{}
+	options := []string{}
+
+	if static.Spec.Source.Credentials != nil {
+		envFrom = append(envFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: *static.Spec.Source.Credentials,
+			},
+		})
+	} else if static.Spec.ServiceAccount == nil {
+		// Without credentials nor ServiceAccount (e.g. annotated for IAM Roles for Service Accounts), the bucket is read anonymously
+		options = append(options, "--no-sign-request")
+	}
+
+	if s3 := static.Spec.Source.S3; s3 != nil {
+		if s3.Endpoint != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "S3_ENDPOINT",
+				Value: s3.Endpoint,
+			})
+			options = append(options, "--endpoint-url \"$S3_ENDPOINT\"")
+		}
+		if s3.Region != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "AWS_DEFAULT_REGION",
+				Value: s3.Region,
+			})
+		}
+		if s3.PathStyle {
+			commands = append(commands, "aws configure set default.s3.addressing_style path")
+		}
+	}
+	if fingerprint {
+		// The listing contains the ETag of each object
+		source, err := url.Parse(static.Spec.Source.URL)
+		if err != nil {
+			return corev1.Container{}, err
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "BUCKET",
+			Value: source.Host,
+		}, corev1.EnvVar{
+			Name:  "PREFIX",
+			Value: strings.TrimPrefix(source.Path, "/"),
+		})
+		commands = append(commands, "set -o pipefail",
+			"aws s3api list-objects-v2 "+strings.Join(options, " ")+" --bucket \"$BUCKET\" --prefix \"$PREFIX\" --query 'Contents[].[Key,ETag]' --output text"+
+				" | sha256sum | cut -d ' ' -f 1 > /dev/termination-log")
+	} else {
+		commands = append(commands, "aws s3 cp --recursive "+strings.Join(options, " ")+" \"$SOURCE\" $DEST/")
+	}
+
+	return corev1.Container{
+		Image: r.Config.S3FetcherImage,
+		Command: []string{
+			"bash",
+			"-c",
+			strings.Join(commands, " && "),
+		},
+		Env:     env,
+		EnvFrom: envFrom,
+	}, nil
+}
+
+// gitFetcher clones the repository and writes the SHA of the served commit as termination message
+func gitFetcher(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error) {
+	source := static.Spec.Source
+
+	command := "rm -rf /tmp/repository" +
+		" && git clone --quiet \"$REPOSITORY\" /tmp/repository" +
+		" && cd /tmp/repository" +
+		" && git checkout --quiet \"${REF:-HEAD}\"" +
+		" && git archive -o /tmp/static-files.tar \"HEAD:$DIRECTORY\"" +
+		" && tar -x -f /tmp/static-files.tar -C $DEST/" +
+		" && git rev-parse HEAD > /dev/termination-log"
+	if fingerprint {
+		// A ref which is not a branch or a tag is a commit, which never changes
+		command = "COMMIT=`git ls-remote \"$REPOSITORY\" \"${REF:-HEAD}\" | head -n 1 | cut -f 1`" +
+			" && echo \"${COMMIT:-$REF}\" > /dev/termination-log"
+	}
+
+	credentials := ""
+	if static.Spec.Source.Credentials != nil {
+		credentials = "if [ -f " + credentialsPath + "/ssh-privatekey ]; then" +
+			" export GIT_SSH_COMMAND=\"ssh -i " + credentialsPath + "/ssh-privatekey -o StrictHostKeyChecking=accept-new\"; fi" +
+			" && if [ -f " + credentialsPath + "/password ]; then" +
+			" git config --global credential.helper '!f() { echo username=`cat " + credentialsPath + "/username 2>/dev/null || echo git`;" +
+			" echo password=`cat " + credentialsPath + "/password`; }; f'; fi && "
+	}
+
+	return corev1.Container{
+		Image: r.Config.GitFetcherImage,
+		Command: []string{
+			"sh",
+			"-c",
+			credentials + command,
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "REPOSITORY",
+				Value: source.URL,
+			},
+			{
+				Name:  "REF",
+				Value: source.Ref,
+			},
+			{
+				Name:  "DIRECTORY",
+				Value: strings.Trim(source.Directory, "/"),
+			},
+		},
+	}, nil
+}
+
+// ociFetcher extracts the filesystem of the image
+func ociFetcher(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error) {
+	command := "crane export \"$IMAGE\" /tmp/static-files.tar && tar -x -f /tmp/static-files.tar -C $DEST/"
+	if fingerprint {
+		command = "crane digest \"$IMAGE\" > /dev/termination-log"
+	}
+	if static.Spec.Source.Credentials != nil {
+		command = "mkdir -p /tmp/docker && cp " + credentialsPath + "/.dockerconfigjson /tmp/docker/config.json" +
+			" && export DOCKER_CONFIG=/tmp/docker && " + command
+	}
+
+	return corev1.Container{
+		Image: r.Config.OciFetcherImage,
+		Command: []string{
+			"sh",
+			"-c",
+			command,
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "IMAGE",
+				Value: strings.TrimPrefix(static.Spec.Source.URL, "oci://"),
+			},
+		},
+	}, nil
+}
+
+// httpFetcher downloads the archive, verifies its checksum if any and extracts it
+func httpFetcher(r *StaticReconciler, static *websitev1beta1.Static, fingerprint bool) (corev1.Container, error) {
+	source, err := url.Parse(static.Spec.Source.URL)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+
+	var extract string
+	switch path := strings.ToLower(source.Path); {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		extract = "tar -x -z -f /tmp/static-files.archive -C $DEST/"
+	case strings.HasSuffix(path, ".tar"):
+		extract = "tar -x -f /tmp/static-files.archive -C $DEST/"
+	case strings.HasSuffix(path, ".zip"):
+		extract = "unzip -q -o /tmp/static-files.archive -d $DEST/"
+	default:
+		return corev1.Container{}, fmt.Errorf("unsupported archive format for source %q", static.Spec.Source.URL)
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "SOURCE",
+			Value: static.Spec.Source.URL,
+		},
+	}
+	options := ""
+	if static.Spec.Source.Credentials != nil {
+		options = "--header \"Authorization: Bearer `cat " + credentialsPath + "/token`\" "
+	}
+
+	if fingerprint {
+		// The headers of the response identify the version of the archive
+		return corev1.Container{
+			Image: r.Config.HttpFetcherImage,
+			Command: []string{
+				"sh",
+				"-c",
+				"wget -q -S --spider " + options + "\"$SOURCE\" 2>&1 | grep -i -e etag: -e last-modified: | sha256sum | cut -d ' ' -f 1 > /dev/termination-log",
+			},
+			Env: env,
+		}, nil
+	}
+
+	commands := []string{"wget -q " + options + "-O /tmp/static-files.archive \"$SOURCE\""}
+
+	if static.Spec.Source.Sha256 != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "SHA256",
+			Value: strings.ToLower(static.Spec.Source.Sha256),
+		})
+		commands = append(commands, "{ echo \"$SHA256  /tmp/static-files.archive\" | sha256sum -c -s"+
+			" || { echo \""+checksumMismatch+": expected $SHA256, got `sha256sum /tmp/static-files.archive | cut -d ' ' -f 1`\" > /dev/termination-log; exit 1; }; }")
+	}
+	commands = append(commands, extract)
+
+	return corev1.Container{
+		Image: r.Config.HttpFetcherImage,
+		Command: []string{
+			"sh",
+			"-c",
+			strings.Join(commands, " && "),
+		},
+		Env: env,
+	}, nil
+}
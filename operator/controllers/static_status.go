@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyReadyStatus reports in the status of the Static the state of the owned Deployment, Service and HPA,
+// whether the site is ready and its URL, and the generation of the spec reconciled
+func (r *StaticReconciler) applyReadyStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	changed := false
+
+	deployment := new(appsv1.Deployment)
+	err := r.Get(ctx, types.NamespacedName{Name: static.Name + "-deployment", Namespace: static.Namespace}, deployment)
+	if err != nil {
+		if err = client.IgnoreNotFound(err); err != nil {
+			return err
+		}
+		changed = setCondition(static, websitev1beta1.DeploymentAvailable, corev1.ConditionFalse, "DeploymentNotFound", "The deployment has not been created yet") || changed
+	} else {
+		status, reason, message := corev1.ConditionUnknown, "DeploymentProgressing", "The deployment has not reported its availability yet"
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == appsv1.DeploymentAvailable {
+				status, reason, message = condition.Status, condition.Reason, condition.Message
+			}
+		}
+		changed = setCondition(static, websitev1beta1.DeploymentAvailable, status, reason, message) || changed
+	}
+
+	service := new(corev1.Service)
+	err = r.Get(ctx, types.NamespacedName{Name: static.Name + "-service", Namespace: static.Namespace}, service)
+	if err != nil {
+		if err = client.IgnoreNotFound(err); err != nil {
+			return err
+		}
+		changed = setCondition(static, websitev1beta1.ServiceReady, corev1.ConditionFalse, "ServiceNotFound", "The service has not been created yet") || changed
+	} else if service.Spec.Type == corev1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		changed = setCondition(static, websitev1beta1.ServiceReady, corev1.ConditionFalse, "LoadBalancerPending", "The load balancer has no address yet") || changed
+	} else {
+		changed = setCondition(static, websitev1beta1.ServiceReady, corev1.ConditionTrue, "ServiceReady", "The service exposes the pods") || changed
+	}
+
+	// The Static is not ready until all the conditions it depends on are satisfied
+	waiting := []string{}
+	for _, conditionType := range []websitev1beta1.StaticConditionType{websitev1beta1.ContentFetched, websitev1beta1.DeploymentAvailable, websitev1beta1.ServiceReady} {
+		if condition := findCondition(static, conditionType); condition != nil && condition.Status != corev1.ConditionTrue {
+			waiting = append(waiting, string(conditionType))
+		}
+	}
+	// The certificate and the route are required when TLS and the Gateway are requested, they have no condition before
+	required := []websitev1beta1.StaticConditionType{}
+	if static.Spec.TLS != nil {
+		required = append(required, websitev1beta1.CertificateReady)
+	}
+	if static.Spec.Gateway != nil {
+		required = append(required, websitev1beta1.RouteAccepted)
+	}
+	for _, conditionType := range required {
+		if condition := findCondition(static, conditionType); condition == nil || condition.Status != corev1.ConditionTrue {
+			waiting = append(waiting, string(conditionType))
+		}
+	}
+	hpa := new(autoscalingv1.HorizontalPodAutoscaler)
+	err = r.Get(ctx, types.NamespacedName{Name: static.Name + "-hpa", Namespace: static.Namespace}, hpa)
+	if err != nil {
+		if err = client.IgnoreNotFound(err); err != nil {
+			return err
+		}
+		waiting = append(waiting, "HorizontalPodAutoscaler")
+	}
+	if len(waiting) == 0 {
+		changed = setCondition(static, websitev1beta1.Ready, corev1.ConditionTrue, "Ready", "The site is served") || changed
+	} else {
+		changed = setCondition(static, websitev1beta1.Ready, corev1.ConditionFalse, "NotReady", "Waiting for "+strings.Join(waiting, ", ")) || changed
+	}
+
+	if url := siteURL(static); url != static.Status.URL {
+		log.Info(fmt.Sprintf("New URL: %s", url))
+		static.Status.URL = url
+		changed = true
+	}
+
+	if static.Status.ObservedGeneration != static.Generation {
+		static.Status.ObservedGeneration = static.Generation
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, static)
+}
+
+// siteURL returns the canonical URL of the site of the Static: the first hostname of the Ingress or the Gateway,
+// or else the address of the Ingress or of the load balancer of the Service, or else the address of the Service in the cluster
+func siteURL(static *websitev1beta1.Static) string {
+	scheme, host, port, path := "http", "", int32(0), "/"
+
+	switch {
+	case len(tlsHosts(static)) > 0:
+		host = tlsHosts(static)[0]
+		if static.Spec.TLS != nil {
+			scheme = "https"
+		}
+		if static.Spec.Ingress != nil && len(static.Spec.Ingress.Hosts) > 0 {
+			path = ingressPaths(static)[0]
+		} else if len(static.Spec.Gateway.Paths) > 0 {
+			path = static.Spec.Gateway.Paths[0]
+		}
+	case static.Spec.Ingress != nil:
+		host = static.Status.IngressAddress
+		path = ingressPaths(static)[0]
+	case static.Spec.Gateway != nil:
+		// The address of the Gateway is not known
+		return ""
+	case serviceType(static) == corev1.ServiceTypeLoadBalancer:
+		host, port = static.Status.ExternalIP, servicePort(static)
+	default:
+		host, port = fmt.Sprintf("%s-service.%s.svc", static.Name, static.Namespace), servicePort(static)
+	}
+	if host == "" {
+		return ""
+	}
+
+	if port != 0 && servesHTTPS(static) {
+		scheme, port = "https", httpsPort(static)
+	}
+	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		port = 0
+	}
+	if port != 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	} else if strings.Contains(host, ":") {
+		// IPv6 address
+		host = "[" + host + "]"
+	}
+
+	u := url.URL{Scheme: scheme, Host: host, Path: path}
+	return strings.TrimSuffix(u.String(), "/")
+}
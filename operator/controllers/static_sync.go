@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncContainerName is the name of the sidecar copying again the assets into the pods
+const syncContainerName = "sync-static-files"
+
+// syncedLog matches the last line logged by the sync sidecar, with timestamps, after a successful copy
+var syncedLog = regexp.MustCompile(`^(\S+) synced (\d+) objects\s*$`)
+
+// releaseScript wraps the script of a fetcher to copy the assets into a new release directory,
+// then to switch atomically the served `html` symlink to this release and to remove the previous releases
+func releaseScript(fetch string) string {
+	return "RELEASE=`date +%s`" +
+		" && export DEST=/mnt/releases/$RELEASE" +
+		" && mkdir -p $DEST" +
+		" && ( " + fetch + " )" +
+		" && ln -sfn releases/$RELEASE /mnt/html.new" +
+		" && mv -T /mnt/html.new /mnt/html" +
+		" && find /mnt/releases -mindepth 1 -maxdepth 1 ! -name $RELEASE -exec rm -rf {} +" +
+		" && echo \"synced `find $DEST -type f | wc -l` objects\""
+}
+
+// createSyncContainers returns from the fetcher of a Static the init container doing the first copy of the assets
+// and the sidecar copying them again periodically
+func createSyncContainers(static *websitev1beta1.Static, fetcher *corev1.Container) (*corev1.Container, *corev1.Container) {
+	shell, fetch := fetcher.Command[0], fetcher.Command[len(fetcher.Command)-1]
+
+	initContainer := fetcher.DeepCopy()
+	initContainer.Command = []string{
+		shell,
+		"-c",
+		releaseScript(fetch),
+	}
+
+	sidecar := fetcher.DeepCopy()
+	sidecar.Name = syncContainerName
+	sidecar.Command = []string{
+		shell,
+		"-c",
+		"while true; do sleep \"$SYNC_INTERVAL\"; " + releaseScript(fetch) + " || rm -rf $DEST; done",
+	}
+	sidecar.Env = append(sidecar.Env, corev1.EnvVar{
+		Name:  "SYNC_INTERVAL",
+		Value: strconv.Itoa(int(static.Spec.SyncInterval.Duration / time.Second)),
+	})
+
+	return initContainer, sidecar
+}
+
+// applySyncStatus reports in the status of the Static the last successful sync of its running pods
+func (r *StaticReconciler) applySyncStatus(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+
+	if static.Spec.SyncInterval == nil {
+		return nil
+	}
+
+	pods := new(corev1.PodList)
+	err := r.List(ctx, pods, client.InNamespace(static.Namespace), client.MatchingLabels{"app": static.Name + "-deployment"})
+	if err != nil {
+		return err
+	}
+
+	lastSyncTime := static.Status.LastSyncTime
+	syncedObjects := static.Status.SyncedObjects
+	tailLines := int64(1)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		raw, err := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container:  syncContainerName,
+			TailLines:  &tailLines,
+			Timestamps: true,
+		}).DoRaw()
+		if err != nil {
+			// The other pods can still be checked
+			log.Info(fmt.Sprintf("Unable to read sync logs of pod %s: %s", pod.Name, err))
+			continue
+		}
+
+		match := syncedLog.FindStringSubmatch(strings.TrimSpace(string(raw)))
+		if match == nil {
+			continue
+		}
+		syncTime, err := time.Parse(time.RFC3339Nano, match[1])
+		if err != nil {
+			continue
+		}
+		if lastSyncTime == nil || lastSyncTime.Time.Before(syncTime) {
+			count, _ := strconv.Atoi(match[2])
+			lastSyncTime = &metav1.Time{Time: syncTime}
+			syncedObjects = int32(count)
+		}
+	}
+
+	if lastSyncTime != static.Status.LastSyncTime {
+		log.Info(fmt.Sprintf("New sync: %d objects at %s", syncedObjects, lastSyncTime))
+		static.Status.LastSyncTime = lastSyncTime
+		static.Status.SyncedObjects = syncedObjects
+		err = r.Status().Update(ctx, static)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
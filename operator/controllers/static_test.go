@@ -2,17 +2,22 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"time"
 
-	"example.com/website/v1alpha1/api/v1alpha1"
+	"example.com/website/v1alpha1/api/v1beta1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("Static controller", func() {
@@ -46,22 +51,26 @@ var _ = Describe("Static controller", func() {
 		}
 	)
 
+	AfterEach(func() {
+		drainEvents()
+	})
+
 	When("a Static resource is created", func() {
 
 		var (
-			created                v1alpha1.Static
+			created                v1beta1.Static
 			expectedOwnerReference metav1.OwnerReference
 		)
 
 		BeforeEach(func() {
-			created = v1alpha1.Static{
+			created = v1beta1.Static{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      key.Name,
 					Namespace: key.Namespace,
 				},
-				Spec: v1alpha1.StaticSpec{
+				Spec: v1beta1.StaticSpec{
 					DiskSize:    *resource.NewQuantity(1024*1024, resource.BinarySI),
-					Source:      "gs://my-bucket/",
+					Source:      v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/"},
 					MinReplicas: 2,
 					MaxReplicas: 4,
 				},
@@ -71,7 +80,7 @@ var _ = Describe("Static controller", func() {
 
 			expectedOwnerReference = metav1.OwnerReference{
 				Kind:               "Static",
-				APIVersion:         "website.example.com/v1alpha1",
+				APIVersion:         "website.example.com/v1beta1",
 				Name:               "my-static",
 				UID:                created.UID,
 				Controller:         func(v bool) *bool { return &v }(true),
@@ -94,12 +103,7 @@ var _ = Describe("Static controller", func() {
 			})
 
 			By("creating an event create-deployment", func() {
-				var eventReceived string
-				select {
-				case eventReceived = <-eventRecorder.Events:
-				case <-time.After(timeout):
-				}
-				Expect(eventReceived).To(ContainSubstring("create-deployment"))
+				Expect(receiveEvent("create-deployment", timeout)).To(ContainSubstring("create-deployment"))
 			})
 
 			By("creating a service owned by the Static resource", func() {
@@ -111,12 +115,7 @@ var _ = Describe("Static controller", func() {
 			})
 
 			By("creating an event create-service", func() {
-				var eventReceived string
-				select {
-				case eventReceived = <-eventRecorder.Events:
-				case <-time.After(timeout):
-				}
-				Expect(eventReceived).To(ContainSubstring("create-service"))
+				Expect(receiveEvent("create-service", timeout)).To(ContainSubstring("create-service"))
 			})
 
 			By("creating an hpa owned by the Static resource", func() {
@@ -128,12 +127,7 @@ var _ = Describe("Static controller", func() {
 			})
 
 			By("creating an event create-hpa", func() {
-				var eventReceived string
-				select {
-				case eventReceived = <-eventRecorder.Events:
-				case <-time.After(timeout):
-				}
-				Expect(eventReceived).To(ContainSubstring("create-hpa"))
+				Expect(receiveEvent("create-hpa", timeout)).To(ContainSubstring("create-hpa"))
 			})
 		})
 
@@ -169,7 +163,7 @@ var _ = Describe("Static controller", func() {
 
 				Specify("the replicas value in the Static status changes", func() {
 					Eventually(func() bool {
-						f := &v1alpha1.Static{}
+						f := &v1beta1.Static{}
 						if err := k8sClient.Get(ctx, key, f); err != nil {
 							return false
 						}
@@ -195,7 +189,7 @@ var _ = Describe("Static controller", func() {
 
 				Specify("the ExternalIP value in the Static status changes", func() {
 					Eventually(func() bool {
-						f := &v1alpha1.Static{}
+						f := &v1beta1.Static{}
 						if err := k8sClient.Get(ctx, key, f); err != nil {
 							return false
 						}
@@ -295,4 +289,2125 @@ var _ = Describe("Static controller", func() {
 			})
 		})
 	})
-})
+
+	When("a Static resource with an S3 source is created", func() {
+
+		var (
+			s3Key = types.NamespacedName{
+				Name:      "my-s3-static",
+				Namespace: "my-ns",
+			}
+
+			s3DeploymentKey = types.NamespacedName{
+				Name:      "my-s3-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s3Key.Name,
+					Namespace: s3Key.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type: v1beta1.SourceTypeS3,
+						URL:  "s3://my-bucket/public",
+						S3: &v1beta1.S3Source{
+							Endpoint:  "http://minio.minio:9000",
+							Region:    "us-east-1",
+							PathStyle: true,
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the assets are copied with the aws cli from the S3 endpoint", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, s3DeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Image).To(Equal("amazon/aws-cli"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("aws configure set default.s3.addressing_style path"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("aws s3 cp --recursive"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("--no-sign-request"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring(`--endpoint-url "$S3_ENDPOINT"`))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "S3_ENDPOINT", Value: "http://minio.minio:9000"}))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: "us-east-1"}))
+		})
+	})
+
+	When("a Static resource with a Git source is created", func() {
+
+		const (
+			commit    = "0123456789abcdef0123456789abcdef01234567"
+			newCommit = "76543210fedcba9876543210fedcba9876543210"
+		)
+
+		var (
+			gitKey = types.NamespacedName{
+				Name:      "my-git-static",
+				Namespace: "my-ns",
+			}
+
+			gitDeploymentKey = types.NamespacedName{
+				Name:      "my-git-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      gitKey.Name,
+					Namespace: gitKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type:      v1beta1.SourceTypeGit,
+						URL:       "https://example.com/my-site.git",
+						Ref:       "v1.0.0",
+						Directory: "/public/",
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the assets are cloned from the repository and the served commit is reported", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, gitDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Image).To(Equal("alpine/git"))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "REPOSITORY", Value: "https://example.com/my-site.git"}))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "REF", Value: "v1.0.0"}))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "DIRECTORY", Value: "public"}))
+
+			By("reading the commit from the termination message of the fetcher", func() {
+				pod := v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-git-static-pod",
+						Namespace: gitKey.Namespace,
+						Labels:    deployment.Spec.Template.Labels,
+					},
+					Spec: deployment.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+				defer k8sClient.Delete(ctx, &pod)
+
+				pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "copy-static-files",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{
+								ExitCode: 0,
+								Message:  commit + "\n",
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+				// Trigger a reconciliation
+				deployment.Status.Replicas = 1
+				Expect(k8sClient.Status().Update(ctx, &deployment)).To(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, gitKey, f); err != nil {
+						return ""
+					}
+					return f.Status.Commit
+				}, timeout, interval).Should(Equal(commit))
+			})
+		})
+	})
+
+	When("a Static resource with an OCI source is created", func() {
+
+		const image = "registry.example.com/my-site@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+		var (
+			ociKey = types.NamespacedName{
+				Name:      "my-oci-static",
+				Namespace: "my-ns",
+			}
+
+			ociDeploymentKey = types.NamespacedName{
+				Name:      "my-oci-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ociKey.Name,
+					Namespace: ociKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:    *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:      v1beta1.Source{Type: v1beta1.SourceTypeOCI, URL: "oci://" + image},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the assets are extracted from the image", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, ociDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Image).To(Equal("gcr.io/go-containerregistry/crane:debug"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring(`crane export "$IMAGE"`))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "IMAGE", Value: image}))
+		})
+	})
+
+	When("a Static resource with an archive source and a checksum is created", func() {
+
+		const sha256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+		var (
+			httpKey = types.NamespacedName{
+				Name:      "my-http-static",
+				Namespace: "my-ns",
+			}
+
+			httpDeploymentKey = types.NamespacedName{
+				Name:      "my-http-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      httpKey.Name,
+					Namespace: httpKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type:   v1beta1.SourceTypeHTTP,
+						URL:    "https://artifacts.example.com/my-site/site.tar.gz",
+						Sha256: sha256,
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the archive is verified and a checksum failure is reported", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, httpDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Image).To(Equal("alpine"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("sha256sum -c"))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("tar -x -z"))
+			Expect(initContainers[0].Env).To(ContainElement(v1.EnvVar{Name: "SHA256", Value: sha256}))
+
+			By("reading the failure from the termination message of the fetcher", func() {
+				pod := v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-http-static-pod",
+						Namespace: httpKey.Namespace,
+						Labels:    deployment.Spec.Template.Labels,
+					},
+					Spec: deployment.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+				defer k8sClient.Delete(ctx, &pod)
+
+				pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "copy-static-files",
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{
+								ExitCode: 1,
+								Message:  "checksum mismatch: expected " + sha256 + ", got 0000",
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, httpKey, f); err != nil {
+						return ""
+					}
+					for _, condition := range f.Status.Conditions {
+						if condition.Type == v1beta1.ContentFetched && condition.Status == v1.ConditionFalse {
+							return condition.Reason
+						}
+					}
+					return ""
+				}, timeout, interval).Should(Equal("ChecksumMismatch"))
+			})
+		})
+	})
+
+	When("a Static resource serving a ConfigMap is created", func() {
+
+		var (
+			inlineKey = types.NamespacedName{
+				Name:      "my-inline-static",
+				Namespace: "my-ns",
+			}
+
+			inlineDeploymentKey = types.NamespacedName{
+				Name:      "my-inline-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			configMap v1.ConfigMap
+			created   v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			configMap = v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "maintenance-page",
+					Namespace: inlineKey.Namespace,
+				},
+				Data: map[string]string{
+					"index.html": "<h1>Under maintenance</h1>",
+				},
+			}
+			Expect(k8sClient.Create(ctx, &configMap)).Should(Succeed())
+
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      inlineKey.Name,
+					Namespace: inlineKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type: v1beta1.SourceTypeInline,
+						ConfigMaps: []v1.LocalObjectReference{
+							{
+								Name: configMap.Name,
+							},
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+			k8sClient.Delete(ctx, &configMap)
+		})
+
+		Specify("the ConfigMap is served without copy and the pods are rolled when it changes", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, inlineDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(BeEmpty())
+			Expect(deployment.Spec.Template.Spec.Volumes).To(HaveLen(2))
+			Expect(deployment.Spec.Template.Spec.Volumes[0].Projected).ToNot(BeNil())
+			Expect(deployment.Spec.Template.Spec.Volumes[1].Name).To(Equal(nginxConfigVolumeName))
+			Expect(deployment.Spec.Template.Spec.Volumes[0].Projected.Sources[0].ConfigMap.Name).To(Equal(configMap.Name))
+
+			initialHash := deployment.Spec.Template.Annotations["website.example.com/content-hash"]
+			Expect(initialHash).ToNot(BeEmpty())
+
+			configMap.Data["index.html"] = "<h1>Back soon</h1>"
+			Expect(k8sClient.Update(ctx, &configMap)).To(Succeed())
+
+			Eventually(func() string {
+				f := &appsv1.Deployment{}
+				if err := k8sClient.Get(ctx, inlineDeploymentKey, f); err != nil {
+					return initialHash
+				}
+				return f.Spec.Template.Annotations["website.example.com/content-hash"]
+			}, timeout, interval).ShouldNot(Equal(initialHash))
+		})
+	})
+
+	When("a Static resource with a shared volume is created", func() {
+
+		var (
+			sharedKey = types.NamespacedName{
+				Name:      "my-shared-static",
+				Namespace: "my-ns",
+			}
+
+			sharedDeploymentKey = types.NamespacedName{
+				Name:      "my-shared-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			sharedJobKey = types.NamespacedName{
+				Name:      "my-shared-static-job",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      sharedKey.Name,
+					Namespace: sharedKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:     resource.MustParse("10Gi"),
+					Source:       v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/media"},
+					SharedVolume: &v1beta1.SharedVolume{},
+					MinReplicas:  2,
+					MaxReplicas:  10,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the volume is populated by a job before being served", func() {
+			var job batchv1.Job
+			Eventually(func() error {
+				return k8sClient.Get(ctx, sharedJobKey, &job)
+			}, timeout, interval).Should(BeNil())
+			Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal("copy-static-files"))
+			Expect(*job.Spec.BackoffLimit).To(Equal(int32(3)))
+			Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(1800)))
+			claimName := job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+			Expect(claimName).To(HavePrefix("my-shared-static-pvc-"))
+
+			var pvc v1.PersistentVolumeClaim
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: sharedKey.Namespace}, &pvc)
+			}, timeout, interval).Should(BeNil())
+			Expect(pvc.Spec.AccessModes).To(ContainElement(v1.ReadOnlyMany))
+			storage := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+			Expect(storage.String()).To(Equal("10Gi"))
+
+			By("reading the failure from the termination message of the fetcher of the job", func() {
+				pod := v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-shared-static-job-pod",
+						Namespace: sharedKey.Namespace,
+						Labels:    map[string]string{"job-name": sharedJobKey.Name},
+					},
+					Spec: job.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+				defer k8sClient.Delete(ctx, &pod)
+
+				pod.Status.ContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "copy-static-files",
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{
+								ExitCode: 1,
+								Message:  "checksum mismatch: expected 1234, got 0000",
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, sharedKey, f); err != nil {
+						return ""
+					}
+					for _, condition := range f.Status.Conditions {
+						if condition.Type == v1beta1.ContentFetched && condition.Status == v1.ConditionFalse {
+							return condition.Reason
+						}
+					}
+					return ""
+				}, timeout, interval).Should(Equal("ChecksumMismatch"))
+			})
+
+			By("waiting for the job to complete before creating the deployment", func() {
+				Consistently(func() error {
+					return k8sClient.Get(ctx, sharedDeploymentKey, &appsv1.Deployment{})
+				}, 2*time.Second, interval).ShouldNot(BeNil())
+
+				job.Status.Succeeded = 1
+				Expect(k8sClient.Status().Update(ctx, &job)).To(Succeed())
+
+				var deployment appsv1.Deployment
+				Eventually(func() error {
+					return k8sClient.Get(ctx, sharedDeploymentKey, &deployment)
+				}, timeout, interval).Should(BeNil())
+				Expect(deployment.Spec.Template.Spec.InitContainers).To(BeEmpty())
+				Expect(deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal(claimName))
+				Expect(deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ReadOnly).To(BeTrue())
+			})
+
+			By("serving the populated volume while a new volume is populated for a new source", func() {
+				f := &v1beta1.Static{}
+				Expect(k8sClient.Get(ctx, sharedKey, f)).To(Succeed())
+				f.Spec.Source.URL = "gs://my-bucket/media-v2"
+				Expect(k8sClient.Update(ctx, f)).To(Succeed())
+
+				var newJob batchv1.Job
+				Eventually(func() string {
+					if err := k8sClient.Get(ctx, sharedJobKey, &newJob); err != nil {
+						return claimName
+					}
+					return newJob.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+				}, timeout, interval).ShouldNot(Equal(claimName))
+				newClaimName := newJob.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+
+				Consistently(func() string {
+					deployment := &appsv1.Deployment{}
+					if err := k8sClient.Get(ctx, sharedDeploymentKey, deployment); err != nil {
+						return ""
+					}
+					return deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+				}, 2*time.Second, interval).Should(Equal(claimName))
+
+				newJob.Status.Succeeded = 1
+				Expect(k8sClient.Status().Update(ctx, &newJob)).To(Succeed())
+
+				Eventually(func() string {
+					deployment := &appsv1.Deployment{}
+					if err := k8sClient.Get(ctx, sharedDeploymentKey, deployment); err != nil {
+						return ""
+					}
+					return deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName
+				}, timeout, interval).Should(Equal(newClaimName))
+
+				Eventually(func() bool {
+					previous := &v1.PersistentVolumeClaim{}
+					err := k8sClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: sharedKey.Namespace}, previous)
+					return err != nil || previous.DeletionTimestamp != nil
+				}, timeout, interval).Should(BeTrue())
+
+				claimName = newClaimName
+			})
+
+			By("deleting the job, the volume and its status when the shared volume is removed", func() {
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, sharedKey, &static); err != nil {
+						return err
+					}
+					static.Spec.SharedVolume = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() bool {
+					f := &batchv1.Job{}
+					err := k8sClient.Get(ctx, sharedJobKey, f)
+					return err != nil || f.DeletionTimestamp != nil
+				}, timeout, interval).Should(BeTrue())
+
+				Eventually(func() bool {
+					f := &v1.PersistentVolumeClaim{}
+					err := k8sClient.Get(ctx, types.NamespacedName{Name: claimName, Namespace: sharedKey.Namespace}, f)
+					return err != nil || f.DeletionTimestamp != nil
+				}, timeout, interval).Should(BeTrue())
+
+				Eventually(func() []v1beta1.StaticConditionType {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, sharedKey, f); err != nil || f.Status.SharedClaimName != "" {
+						return nil
+					}
+					var types []v1beta1.StaticConditionType
+					for _, condition := range f.Status.Conditions {
+						types = append(types, condition.Type)
+					}
+					return types
+				}, timeout, interval).ShouldNot(Or(BeNil(), ContainElement(v1beta1.SharedVolumePopulated)))
+
+				Eventually(func() int {
+					f := &appsv1.Deployment{}
+					if err := k8sClient.Get(ctx, sharedDeploymentKey, f); err != nil {
+						return 0
+					}
+					return len(f.Spec.Template.Spec.InitContainers)
+				}, timeout, interval).Should(Equal(1))
+			})
+		})
+	})
+
+	When("a Static resource with a private source is created", func() {
+
+		var (
+			privateKey = types.NamespacedName{
+				Name:      "my-private-static",
+				Namespace: "my-ns",
+			}
+
+			privateDeploymentKey = types.NamespacedName{
+				Name:      "my-private-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      privateKey.Name,
+					Namespace: privateKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type: v1beta1.SourceTypeGCS,
+						URL:  "gs://my-private-bucket/docs",
+						Credentials: &v1.LocalObjectReference{
+							Name: "docs-reader",
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the credentials are mounted into the fetcher", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, privateDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			volumes := deployment.Spec.Template.Spec.Volumes
+			Expect(volumes).To(HaveLen(3))
+			Expect(volumes[1].Secret).ToNot(BeNil())
+			Expect(volumes[1].Secret.SecretName).To(Equal("docs-reader"))
+			Expect(volumes[2].Name).To(Equal(nginxConfigVolumeName))
+
+			fetcher := deployment.Spec.Template.Spec.InitContainers[0]
+			Expect(fetcher.Command[2]).To(HavePrefix("gcloud auth activate-service-account --key-file=/etc/static-credentials/key.json && "))
+			Expect(fetcher.VolumeMounts).To(ContainElement(v1.VolumeMount{
+				Name:      "credentials",
+				MountPath: "/etc/static-credentials",
+				ReadOnly:  true,
+			}))
+		})
+	})
+
+	When("a Static resource with its own ServiceAccount is created", func() {
+
+		var (
+			identityKey = types.NamespacedName{
+				Name:      "my-identity-static",
+				Namespace: "my-ns",
+			}
+
+			identityDeploymentKey = types.NamespacedName{
+				Name:      "my-identity-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			identityServiceAccountKey = types.NamespacedName{
+				Name:      "my-identity-static-serviceaccount",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      identityKey.Name,
+					Namespace: identityKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-private-bucket/docs"},
+					ServiceAccount: &v1beta1.ServiceAccount{
+						Annotations: map[string]string{
+							"iam.gke.io/gcp-service-account": "docs-reader@my-project.iam.gserviceaccount.com",
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the pods run with a ServiceAccount owned by the Static resource", func() {
+			var serviceAccount v1.ServiceAccount
+			Eventually(func() error {
+				return k8sClient.Get(ctx, identityServiceAccountKey, &serviceAccount)
+			}, timeout, interval).Should(BeNil())
+			Expect(serviceAccount.Annotations).To(HaveKeyWithValue("iam.gke.io/gcp-service-account", "docs-reader@my-project.iam.gserviceaccount.com"))
+			Expect(serviceAccount.OwnerReferences).To(HaveLen(1))
+			Expect(serviceAccount.OwnerReferences[0].Name).To(Equal(identityKey.Name))
+
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, identityDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal(identityServiceAccountKey.Name))
+
+			By("removing the annotations removed from the spec and using the identity for S3", func() {
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, identityKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Source = v1beta1.Source{Type: v1beta1.SourceTypeS3, URL: "s3://my-private-bucket/docs"}
+					static.Spec.ServiceAccount.Annotations = map[string]string{
+						"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/docs-reader",
+					}
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() map[string]string {
+					f := &v1.ServiceAccount{}
+					if err := k8sClient.Get(ctx, identityServiceAccountKey, f); err != nil {
+						return nil
+					}
+					return f.Annotations
+				}, timeout, interval).Should(HaveKey("eks.amazonaws.com/role-arn"))
+
+				f := &v1.ServiceAccount{}
+				Expect(k8sClient.Get(ctx, identityServiceAccountKey, f)).To(Succeed())
+				Expect(f.Annotations).NotTo(HaveKey("iam.gke.io/gcp-service-account"))
+
+				Eventually(func() string {
+					f := &appsv1.Deployment{}
+					if err := k8sClient.Get(ctx, identityDeploymentKey, f); err != nil || len(f.Spec.Template.Spec.InitContainers) == 0 {
+						return ""
+					}
+					return f.Spec.Template.Spec.InitContainers[0].Image
+				}, timeout, interval).Should(Equal("amazon/aws-cli"))
+
+				Expect(k8sClient.Get(ctx, identityDeploymentKey, &deployment)).To(Succeed())
+				Expect(deployment.Spec.Template.Spec.InitContainers[0].Command[2]).NotTo(ContainSubstring("--no-sign-request"))
+			})
+		})
+	})
+
+	When("a Static resource with a sync interval is created", func() {
+
+		var (
+			syncKey = types.NamespacedName{
+				Name:      "my-sync-static",
+				Namespace: "my-ns",
+			}
+
+			syncDeploymentKey = types.NamespacedName{
+				Name:      "my-sync-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      syncKey.Name,
+					Namespace: syncKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:     *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:       v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					SyncInterval: &metav1.Duration{Duration: 5 * time.Minute},
+					MinReplicas:  1,
+					MaxReplicas:  2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("a sidecar copies the assets again periodically into a new release", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, syncDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring(`gsutil cp -R "$SOURCE/*" $DEST/`))
+			Expect(initContainers[0].Command[2]).To(ContainSubstring("mv -T /mnt/html.new /mnt/html"))
+
+			containers := deployment.Spec.Template.Spec.Containers
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[0].VolumeMounts[0].MountPath).To(Equal("/usr/share/nginx"))
+			Expect(containers[1].Name).To(Equal("sync-static-files"))
+			Expect(containers[1].Command[2]).To(ContainSubstring(`sleep "$SYNC_INTERVAL"`))
+			Expect(containers[1].Env).To(ContainElement(v1.EnvVar{Name: "SYNC_INTERVAL", Value: "300"}))
+		})
+	})
+
+	When("a Static resource with a poll interval is created", func() {
+
+		const (
+			commit    = "0123456789abcdef0123456789abcdef01234567"
+			newCommit = "76543210fedcba9876543210fedcba9876543210"
+		)
+
+		var (
+			pollKey = types.NamespacedName{
+				Name:      "my-poll-static",
+				Namespace: "my-ns",
+			}
+
+			pollDeploymentKey = types.NamespacedName{
+				Name:      "my-poll-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			pollCheckJobKey = types.NamespacedName{
+				Name:      "my-poll-static-check-job",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pollKey.Name,
+					Namespace: pollKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source: v1beta1.Source{
+						Type: v1beta1.SourceTypeGit,
+						URL:  "https://example.com/my-site.git",
+						Ref:  "main",
+					},
+					PollInterval: &metav1.Duration{Duration: time.Hour},
+					MinReplicas:  1,
+					MaxReplicas:  2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the pods are restarted when the content of the source changes", func() {
+			var job batchv1.Job
+			Eventually(func() error {
+				return k8sClient.Get(ctx, pollCheckJobKey, &job)
+			}, timeout, interval).Should(BeNil())
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal("check-static-files"))
+			Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring(`git ls-remote "$REPOSITORY" "${REF:-HEAD}"`))
+
+			// completeCheck simulates the check Job writing the fingerprint in the termination message of its pod
+			completeCheck := func(job *batchv1.Job, fingerprint string, finished time.Time) {
+				pod := v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-poll-static-check-pod-" + fingerprint[:8],
+						Namespace: pollKey.Namespace,
+						Labels: map[string]string{
+							"job-name": pollCheckJobKey.Name,
+						},
+					},
+					Spec: job.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, &pod)).To(Succeed())
+				// No kubelet stops the pod, it is deleted immediately
+				defer k8sClient.Delete(ctx, &pod, client.GracePeriodSeconds(0))
+
+				pod.Status.ContainerStatuses = []v1.ContainerStatus{
+					{
+						Name: "check-static-files",
+						State: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{
+								ExitCode: 0,
+								Message:  fingerprint + "\n",
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+				completion := metav1.NewTime(finished)
+				job.Status.Succeeded = 1
+				job.Status.CompletionTime = &completion
+				Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+
+				Eventually(func() string {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, pollKey, &static); err != nil {
+						return ""
+					}
+					return static.Status.ContentHash
+				}, timeout, interval).Should(Equal(fingerprint))
+			}
+
+			By("keeping the pods at the first fingerprint", func() {
+				// The check is finished since longer than the poll interval, so that it is run again
+				completeCheck(&job, commit, time.Now().Add(-2*time.Hour))
+
+				Consistently(func() string {
+					var deployment appsv1.Deployment
+					if err := k8sClient.Get(ctx, pollDeploymentKey, &deployment); err != nil {
+						return ""
+					}
+					return deployment.Spec.Template.Annotations["website.example.com/content-hash"]
+				}, time.Second, interval).Should(BeEmpty())
+			})
+
+			By("restarting the pods when a new check reads a different fingerprint", func() {
+				var next batchv1.Job
+				Eventually(func() bool {
+					if err := k8sClient.Get(ctx, pollCheckJobKey, &next); err != nil {
+						return false
+					}
+					return next.UID != job.UID
+				}, timeout, interval).Should(BeTrue())
+
+				completeCheck(&next, newCommit, time.Now())
+
+				Eventually(func() string {
+					var deployment appsv1.Deployment
+					if err := k8sClient.Get(ctx, pollDeploymentKey, &deployment); err != nil {
+						return ""
+					}
+					return deployment.Spec.Template.Annotations["website.example.com/content-hash"]
+				}, timeout, interval).Should(Equal(newCommit))
+				Expect(receiveEvent("update-content", timeout)).To(ContainSubstring("has changed"))
+			})
+		})
+	})
+
+	When("a Static resource with an ingress is created", func() {
+
+		var (
+			ingressKey = types.NamespacedName{
+				Name:      "my-ingress-static",
+				Namespace: "my-ns",
+			}
+
+			ingressServiceKey = types.NamespacedName{
+				Name:      "my-ingress-static-service",
+				Namespace: "my-ns",
+			}
+
+			ingressIngressKey = types.NamespacedName{
+				Name:      "my-ingress-static-ingress",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ingressKey.Name,
+					Namespace: ingressKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Ingress: &v1beta1.Ingress{
+						Hosts:            []string{"docs.example.com", "www.docs.example.com"},
+						Paths:            []string{"/docs"},
+						IngressClassName: "nginx",
+						Annotations: map[string]string{
+							"nginx.ingress.kubernetes.io/proxy-body-size": "1m",
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the Static resource is exposed by an ingress and a ClusterIP service", func() {
+			var service v1.Service
+			Eventually(func() error {
+				return k8sClient.Get(ctx, ingressServiceKey, &service)
+			}, timeout, interval).Should(BeNil())
+			Expect(service.Spec.Type).To(Equal(v1.ServiceTypeClusterIP))
+
+			var ingress networkingv1beta1.Ingress
+			Eventually(func() error {
+				return k8sClient.Get(ctx, ingressIngressKey, &ingress)
+			}, timeout, interval).Should(BeNil())
+			Expect(ingress.Annotations).To(HaveKeyWithValue("kubernetes.io/ingress.class", "nginx"))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/proxy-body-size", "1m"))
+			Expect(ingress.Spec.Rules).To(HaveLen(2))
+			Expect(ingress.Spec.Rules[0].Host).To(Equal("docs.example.com"))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/docs"))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName).To(Equal(ingressServiceKey.Name))
+
+			By("reporting the address of the ingress", func() {
+				ingress.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+					{
+						IP: "203.0.113.10",
+					},
+				}
+				Expect(k8sClient.Status().Update(ctx, &ingress)).To(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, ingressKey, f); err != nil {
+						return ""
+					}
+					return f.Status.IngressAddress
+				}, timeout, interval).Should(Equal("203.0.113.10"))
+			})
+		})
+	})
+
+	When("a Static resource attached to a Gateway is created", func() {
+
+		var (
+			gatewayKey = types.NamespacedName{
+				Name:      "my-gateway-static",
+				Namespace: "my-ns",
+			}
+
+			gatewayRouteKey = types.NamespacedName{
+				Name:      "my-gateway-static-httproute",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      gatewayKey.Name,
+					Namespace: gatewayKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Gateway: &v1beta1.Gateway{
+						Name:      "my-gateway",
+						Namespace: "gateways",
+						Hostnames: []string{"docs.example.com"},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the Static resource is exposed by an HTTPRoute", func() {
+			route := new(unstructured.Unstructured)
+			route.SetAPIVersion("gateway.networking.k8s.io/v1")
+			route.SetKind("HTTPRoute")
+			Eventually(func() error {
+				return k8sClient.Get(ctx, gatewayRouteKey, route)
+			}, timeout, interval).Should(BeNil())
+			Expect(route.GetOwnerReferences()).To(HaveLen(1))
+
+			parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(ConsistOf(map[string]interface{}{"name": "my-gateway", "namespace": "gateways"}))
+			hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			Expect(hostnames).To(ConsistOf("docs.example.com"))
+
+			By("reporting the acceptance of the HTTPRoute", func() {
+				Expect(unstructured.SetNestedSlice(route.Object, []interface{}{
+					map[string]interface{}{
+						"parentRef": map[string]interface{}{
+							"name":      "my-gateway",
+							"namespace": "gateways",
+						},
+						"controllerName": "example.com/gateway-controller",
+						"conditions": []interface{}{
+							map[string]interface{}{
+								"type":    "Accepted",
+								"status":  "False",
+								"reason":  "NotAllowedByListeners",
+								"message": "The namespace is not allowed",
+							},
+						},
+					},
+				}, "status", "parents")).To(Succeed())
+				Expect(k8sClient.Status().Update(ctx, route)).To(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, gatewayKey, f); err != nil {
+						return ""
+					}
+					condition := findCondition(f, v1beta1.RouteAccepted)
+					if condition == nil {
+						return ""
+					}
+					return condition.Reason
+				}, timeout, interval).Should(Equal("NotAllowedByListeners"))
+			})
+
+			By("removing the acceptance of the HTTPRoute when the Gateway is removed", func() {
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, gatewayKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Gateway = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() bool {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, gatewayKey, f); err != nil {
+						return false
+					}
+					return findCondition(f, v1beta1.RouteAccepted) == nil
+				}, timeout, interval).Should(BeTrue())
+			})
+		})
+	})
+
+	When("a Static resource with a configured service is created", func() {
+
+		var (
+			serviceStaticKey = types.NamespacedName{
+				Name:      "my-service-static",
+				Namespace: "my-ns",
+			}
+
+			serviceServiceKey = types.NamespacedName{
+				Name:      "my-service-static-service",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceStaticKey.Name,
+					Namespace: serviceStaticKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Service: &v1beta1.Service{
+						Type:                     v1.ServiceTypeLoadBalancer,
+						Port:                     8080,
+						LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+						ExternalTrafficPolicy:    v1.ServiceExternalTrafficPolicyTypeLocal,
+						Annotations: map[string]string{
+							"networking.gke.io/load-balancer-type": "Internal",
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the service is configured as requested", func() {
+			var service v1.Service
+			Eventually(func() error {
+				return k8sClient.Get(ctx, serviceServiceKey, &service)
+			}, timeout, interval).Should(BeNil())
+			Expect(service.Annotations).To(HaveKeyWithValue("networking.gke.io/load-balancer-type", "Internal"))
+			Expect(service.Spec.Type).To(Equal(v1.ServiceTypeLoadBalancer))
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(8080)))
+			Expect(service.Spec.Ports[0].TargetPort.IntValue()).To(Equal(80))
+			Expect(service.Spec.LoadBalancerSourceRanges).To(ConsistOf("10.0.0.0/8"))
+			Expect(service.Spec.ExternalTrafficPolicy).To(Equal(v1.ServiceExternalTrafficPolicyTypeLocal))
+
+			By("keeping the fields not set in the spec", func() {
+				service.Spec.SessionAffinity = v1.ServiceAffinityClientIP
+				Expect(k8sClient.Update(ctx, &service)).To(Succeed())
+
+				Consistently(func() v1.ServiceAffinity {
+					f := &v1.Service{}
+					if err := k8sClient.Get(ctx, serviceServiceKey, f); err != nil {
+						return ""
+					}
+					return f.Spec.SessionAffinity
+				}, 2*time.Second, interval).Should(Equal(v1.ServiceAffinityClientIP))
+			})
+
+			By("removing the source ranges and the annotations removed from the spec", func() {
+				Eventually(func() error {
+					var service v1.Service
+					if err := k8sClient.Get(ctx, serviceServiceKey, &service); err != nil {
+						return err
+					}
+					service.Annotations["example.com/other-controller"] = "kept"
+					return k8sClient.Update(ctx, &service)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, serviceStaticKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Service.LoadBalancerSourceRanges = nil
+					static.Spec.Service.Annotations = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() []string {
+					f := &v1.Service{}
+					if err := k8sClient.Get(ctx, serviceServiceKey, f); err != nil {
+						return nil
+					}
+					return f.Spec.LoadBalancerSourceRanges
+				}, timeout, interval).Should(BeEmpty())
+
+				f := &v1.Service{}
+				Expect(k8sClient.Get(ctx, serviceServiceKey, f)).To(Succeed())
+				Expect(f.Annotations).NotTo(HaveKey("networking.gke.io/load-balancer-type"))
+				Expect(f.Annotations).To(HaveKeyWithValue("example.com/other-controller", "kept"))
+			})
+
+			By("resetting the source ranges and the external traffic policy when the service configuration is removed", func() {
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, serviceStaticKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Service.LoadBalancerSourceRanges = []string{"10.0.0.0/8"}
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() []string {
+					f := &v1.Service{}
+					if err := k8sClient.Get(ctx, serviceServiceKey, f); err != nil {
+						return nil
+					}
+					return f.Spec.LoadBalancerSourceRanges
+				}, timeout, interval).Should(ConsistOf("10.0.0.0/8"))
+
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, serviceStaticKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Service = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() v1.ServiceExternalTrafficPolicyType {
+					f := &v1.Service{}
+					if err := k8sClient.Get(ctx, serviceServiceKey, f); err != nil {
+						return ""
+					}
+					return f.Spec.ExternalTrafficPolicy
+				}, timeout, interval).Should(Equal(v1.ServiceExternalTrafficPolicyTypeCluster))
+
+				f := &v1.Service{}
+				Expect(k8sClient.Get(ctx, serviceServiceKey, f)).To(Succeed())
+				Expect(f.Spec.LoadBalancerSourceRanges).To(BeEmpty())
+				Expect(f.Spec.HealthCheckNodePort).To(BeZero())
+			})
+		})
+	})
+
+	When("a Static resource with TLS is created", func() {
+
+		var (
+			tlsKey = types.NamespacedName{
+				Name:      "my-tls-static",
+				Namespace: "my-ns",
+			}
+
+			tlsIngressKey = types.NamespacedName{
+				Name:      "my-tls-static-ingress",
+				Namespace: "my-ns",
+			}
+
+			tlsCertificateKey = types.NamespacedName{
+				Name:      "my-tls-static-certificate",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tlsKey.Name,
+					Namespace: tlsKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Ingress: &v1beta1.Ingress{
+						Hosts: []string{"docs.example.com"},
+					},
+					TLS:         &v1beta1.TLS{},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("a certificate is requested and served by the ingress", func() {
+			certificate := new(unstructured.Unstructured)
+			certificate.SetAPIVersion("cert-manager.io/v1")
+			certificate.SetKind("Certificate")
+			Eventually(func() error {
+				return k8sClient.Get(ctx, tlsCertificateKey, certificate)
+			}, timeout, interval).Should(BeNil())
+			Expect(certificate.GetOwnerReferences()).To(HaveLen(1))
+
+			dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+			Expect(dnsNames).To(ConsistOf("docs.example.com"))
+			secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+			Expect(secretName).To(Equal("my-tls-static-tls"))
+			issuerName, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "name")
+			Expect(issuerName).To(Equal("letsencrypt"))
+
+			var ingress networkingv1beta1.Ingress
+			Eventually(func() error {
+				return k8sClient.Get(ctx, tlsIngressKey, &ingress)
+			}, timeout, interval).Should(BeNil())
+			Expect(ingress.Spec.TLS).To(ConsistOf(networkingv1beta1.IngressTLS{
+				Hosts:      []string{"docs.example.com"},
+				SecretName: "my-tls-static-tls",
+			}))
+
+			By("waiting for the certificate before the Static is ready", func() {
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, tlsKey, f); err != nil {
+						return ""
+					}
+					if condition := findCondition(f, v1beta1.Ready); condition != nil {
+						return condition.Message
+					}
+					return ""
+				}, timeout, interval).Should(ContainSubstring("CertificateReady"))
+			})
+
+			By("reporting the readiness and the expiration of the certificate", func() {
+				Expect(unstructured.SetNestedField(certificate.Object, "2030-01-01T00:00:00Z", "status", "notAfter")).To(Succeed())
+				Expect(unstructured.SetNestedSlice(certificate.Object, []interface{}{
+					map[string]interface{}{
+						"type":    "Ready",
+						"status":  "True",
+						"reason":  "Ready",
+						"message": "Certificate is up to date and has not expired",
+					},
+				}, "status", "conditions")).To(Succeed())
+				Expect(k8sClient.Status().Update(ctx, certificate)).To(Succeed())
+
+				Eventually(func() *metav1.Time {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, tlsKey, f); err != nil {
+						return nil
+					}
+					return f.Status.CertificateNotAfter
+				}, timeout, interval).ShouldNot(BeNil())
+
+				f := &v1beta1.Static{}
+				Expect(k8sClient.Get(ctx, tlsKey, f)).To(Succeed())
+				Expect(f.Status.CertificateNotAfter.Year()).To(Equal(2030))
+				Expect(f.Status.CertificateSecretName).To(Equal("my-tls-static-tls"))
+				Expect(f.Status.URL).To(Equal("https://docs.example.com"))
+				Expect(findCondition(f, v1beta1.CertificateReady).Status).To(Equal(v1.ConditionTrue))
+			})
+
+			By("reporting once why the certificate cannot be requested", func() {
+				drainEvents()
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, tlsKey, &static); err != nil {
+						return err
+					}
+					static.Spec.Ingress.Hosts = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() string {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, tlsKey, f); err != nil {
+						return ""
+					}
+					if condition := findCondition(f, v1beta1.CertificateReady); condition != nil {
+						return condition.Reason
+					}
+					return ""
+				}, timeout, interval).Should(Equal("NoHostnames"))
+				Expect(receiveEvent("tls-without-hosts", timeout)).To(ContainSubstring("no hostname"))
+
+				f := &v1beta1.Static{}
+				Expect(k8sClient.Get(ctx, tlsKey, f)).To(Succeed())
+				Expect(f.Status.CertificateNotAfter).To(BeNil())
+				Expect(f.Status.CertificateSecretName).To(BeEmpty())
+
+				// Trigger a reconciliation
+				f.Annotations = map[string]string{"example.com/touched": "true"}
+				Expect(k8sClient.Update(ctx, f)).To(Succeed())
+				Expect(receiveEvent("tls-without-hosts", 2*time.Second)).To(BeEmpty())
+			})
+
+			By("removing the certificate from the status when TLS is removed", func() {
+				Eventually(func() error {
+					var static v1beta1.Static
+					if err := k8sClient.Get(ctx, tlsKey, &static); err != nil {
+						return err
+					}
+					static.Spec.TLS = nil
+					return k8sClient.Update(ctx, &static)
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func() bool {
+					f := &v1beta1.Static{}
+					if err := k8sClient.Get(ctx, tlsKey, f); err != nil {
+						return false
+					}
+					return findCondition(f, v1beta1.CertificateReady) == nil
+				}, timeout, interval).Should(BeTrue())
+			})
+		})
+	})
+
+	When("a Static resource serving HTTPS is created", func() {
+
+		var (
+			httpsKey = types.NamespacedName{
+				Name:      "my-https-static",
+				Namespace: "my-ns",
+			}
+
+			httpsDeploymentKey = types.NamespacedName{
+				Name:      "my-https-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			httpsServiceKey = types.NamespacedName{
+				Name:      "my-https-static-service",
+				Namespace: "my-ns",
+			}
+
+			httpsConfigMapKey = types.NamespacedName{
+				Name:      "my-https-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      httpsKey.Name,
+					Namespace: httpsKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					HTTPS: &v1beta1.HTTPS{
+						SecretName:   "docs-example-com-tls",
+						RedirectHTTP: true,
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("nginx serves HTTPS with the certificate and redirects HTTP", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, httpsConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			Expect(configMap.Data["nginx.conf"]).To(ContainSubstring("listen       443 ssl;"))
+			Expect(configMap.Data["nginx.conf"]).To(ContainSubstring("return 301 https://$host$request_uri;"))
+
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, httpsDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("website.example.com/config-hash"))
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Ports).To(ContainElement(v1.ContainerPort{ContainerPort: 443, Protocol: v1.ProtocolTCP}))
+			Expect(container.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "nginx-config", MountPath: "/etc/nginx/nginx.conf", SubPath: "nginx.conf", ReadOnly: true}))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(WithTransform(func(volume v1.Volume) string {
+				if volume.Secret == nil {
+					return ""
+				}
+				return volume.Secret.SecretName
+			}, Equal("docs-example-com-tls"))))
+
+			var service v1.Service
+			Eventually(func() error {
+				return k8sClient.Get(ctx, httpsServiceKey, &service)
+			}, timeout, interval).Should(BeNil())
+			Expect(service.Spec.Ports).To(HaveLen(2))
+			Expect(service.Spec.Ports[1].Name).To(Equal("https"))
+			Expect(service.Spec.Ports[1].Port).To(Equal(int32(443)))
+		})
+	})
+
+	When("a Static resource with a serving configuration is created", func() {
+
+		var (
+			servingKey = types.NamespacedName{
+				Name:      "my-serving-static",
+				Namespace: "my-ns",
+			}
+
+			servingDeploymentKey = types.NamespacedName{
+				Name:      "my-serving-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			servingConfigMapKey = types.NamespacedName{
+				Name:      "my-serving-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      servingKey.Name,
+					Namespace: servingKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Serving: &v1beta1.Serving{
+						IndexFiles: []string{"index.html"},
+						TryFiles:   []string{"$uri", "$uri.html", "$uri/", "=404"},
+						Gzip:       &v1beta1.Gzip{},
+						ErrorPages: []v1beta1.ErrorPage{
+							{
+								Codes: []int32{404},
+								Page:  "/404.html",
+							},
+						},
+						MimeTypes: map[string]string{
+							"wasm": "application/wasm",
+						},
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("nginx is configured from the serving configuration", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, servingConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			config := configMap.Data["nginx.conf"]
+			Expect(config).To(ContainSubstring(`index  "index.html";`))
+			Expect(config).To(ContainSubstring(`try_files  "$uri" "$uri.html" "$uri/" "=404";`))
+			Expect(config).To(ContainSubstring("gzip_min_length  1024;"))
+			Expect(config).To(ContainSubstring(`error_page  404  "/404.html";`))
+			Expect(config).To(ContainSubstring(`"application/wasm"  "wasm";`))
+			Expect(config).NotTo(ContainSubstring("listen       443 ssl;"))
+
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, servingDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("website.example.com/config-hash"))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(WithTransform(func(volume v1.Volume) string {
+				if volume.ConfigMap == nil {
+					return ""
+				}
+				return volume.ConfigMap.Name
+			}, Equal("my-serving-static-nginx"))))
+		})
+	})
+
+	When("a Static resource serving a single-page application is created", func() {
+
+		var (
+			spaKey = types.NamespacedName{
+				Name:      "my-spa-static",
+				Namespace: "my-ns",
+			}
+
+			spaConfigMapKey = types.NamespacedName{
+				Name:      "my-spa-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      spaKey.Name,
+					Namespace: spaKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:    *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:      v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/app"},
+					SPA:         true,
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the routes fall back to the index file and the missing assets are not found", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, spaConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			config := configMap.Data["nginx.conf"]
+			Expect(config).To(ContainSubstring(`try_files  "$uri" "$uri/" "/index.html";`))
+			Expect(config).To(ContainSubstring("try_files  $uri =404;"))
+		})
+	})
+
+	When("a Static resource with redirects is created", func() {
+
+		var (
+			redirectsKey = types.NamespacedName{
+				Name:      "my-redirects-static",
+				Namespace: "my-ns",
+			}
+
+			redirectsDeploymentKey = types.NamespacedName{
+				Name:      "my-redirects-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			redirectsConfigMapKey = types.NamespacedName{
+				Name:      "my-redirects-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      redirectsKey.Name,
+					Namespace: redirectsKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Redirects: []v1beta1.Redirect{
+						{
+							From: "/old.html",
+							To:   "/new.html",
+						},
+						{
+							From:   "^/docs/(.*)$",
+							To:     "https://docs.example.com/$1",
+							Status: 308,
+							Regex:  true,
+						},
+					},
+					ImportRedirects: true,
+					MinReplicas:     1,
+					MaxReplicas:     2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("nginx serves the redirects of the spec and of the _redirects file", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redirectsConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			config := configMap.Data["nginx.conf"]
+			Expect(config).To(ContainSubstring(`location = "/old.html" {
+            return  301 "/new.html";`))
+			Expect(config).To(ContainSubstring(`location ~ "^/docs/(.*)$" {
+            return  308 "https://docs.example.com/$1";`))
+			Expect(config).To(ContainSubstring("include  /etc/nginx/redirects.conf;"))
+			Expect(configMap.Data).To(HaveKey("40-import-redirects.sh"))
+
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redirectsDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "nginx-config", MountPath: "/docker-entrypoint.d/40-import-redirects.sh", SubPath: "40-import-redirects.sh", ReadOnly: true}))
+		})
+	})
+
+	When("a Static resource with response headers is created", func() {
+
+		var (
+			headersKey = types.NamespacedName{
+				Name:      "my-headers-static",
+				Namespace: "my-ns",
+			}
+
+			headersConfigMapKey = types.NamespacedName{
+				Name:      "my-headers-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      headersKey.Name,
+					Namespace: headersKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Headers: []v1beta1.ResponseHeaders{
+						{
+							Path: "/embed/",
+							Values: map[string]string{
+								"X-Frame-Options": "SAMEORIGIN",
+							},
+						},
+					},
+					SecurityHeaders: "strict",
+					MinReplicas:     1,
+					MaxReplicas:     2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("nginx adds the headers of the paths and the security headers", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, headersConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			config := configMap.Data["nginx.conf"]
+			Expect(config).To(ContainSubstring(`default  "DENY";
+        "~^/embed/"  "SAMEORIGIN";`))
+			Expect(config).To(ContainSubstring(`add_header  "X-Frame-Options" $static_header_5 always;`))
+			Expect(config).To(ContainSubstring(`add_header  "Strict-Transport-Security" $static_header_3 always;`))
+		})
+	})
+
+	When("a Static resource with caching rules is created", func() {
+
+		var (
+			cachingKey = types.NamespacedName{
+				Name:      "my-caching-static",
+				Namespace: "my-ns",
+			}
+
+			cachingConfigMapKey = types.NamespacedName{
+				Name:      "my-caching-static-nginx",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			etag := false
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cachingKey.Name,
+					Namespace: cachingKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize: *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:   v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					Caching: &v1beta1.Caching{
+						Rules: []v1beta1.CacheRule{
+							{
+								Pattern:   "/assets/**",
+								MaxAge:    &metav1.Duration{Duration: 8760 * time.Hour},
+								Immutable: true,
+							},
+							{
+								Pattern: "*.html",
+								NoCache: true,
+							},
+						},
+						ETag: &etag,
+					},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("nginx sets the Cache-Control header of the paths", func() {
+			var configMap v1.ConfigMap
+			Eventually(func() error {
+				return k8sClient.Get(ctx, cachingConfigMapKey, &configMap)
+			}, timeout, interval).Should(BeNil())
+			config := configMap.Data["nginx.conf"]
+			Expect(config).To(ContainSubstring(`"~^/assets/.*$"  "public, max-age=31536000, immutable";`))
+			Expect(config).To(ContainSubstring(`"~(^|/)[^/]*\\.html$"  "no-cache";`))
+			Expect(config).To(ContainSubstring(`add_header  "Cache-Control" $static_header_0;`))
+			Expect(config).To(ContainSubstring("etag  off;"))
+			Expect(config).NotTo(ContainSubstring("if_modified_since  off;"))
+		})
+	})
+
+	When("the pods and the load balancer of a Static resource are ready", func() {
+
+		var (
+			readyKey = types.NamespacedName{
+				Name:      "my-ready-static",
+				Namespace: "my-ns",
+			}
+
+			readyDeploymentKey = types.NamespacedName{
+				Name:      "my-ready-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			readyServiceKey = types.NamespacedName{
+				Name:      "my-ready-static-service",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      readyKey.Name,
+					Namespace: readyKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:    *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:      v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the Static is ready and reports its URL", func() {
+			var f v1beta1.Static
+			Eventually(func() v1.ConditionStatus {
+				if err := k8sClient.Get(ctx, readyKey, &f); err != nil {
+					return ""
+				}
+				if condition := findCondition(&f, v1beta1.Ready); condition != nil {
+					return condition.Status
+				}
+				return ""
+			}, timeout, interval).Should(Equal(v1.ConditionFalse))
+			Expect(f.Status.ObservedGeneration).To(Equal(f.Generation))
+
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, readyDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			deployment.Status.Conditions = []appsv1.DeploymentCondition{
+				{
+					Type:   appsv1.DeploymentAvailable,
+					Status: v1.ConditionTrue,
+					Reason: "MinimumReplicasAvailable",
+				},
+			}
+			Expect(k8sClient.Status().Update(ctx, &deployment)).Should(Succeed())
+
+			var service v1.Service
+			Eventually(func() error {
+				return k8sClient.Get(ctx, readyServiceKey, &service)
+			}, timeout, interval).Should(BeNil())
+			service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+				{
+					IP: "203.0.113.10",
+				},
+			}
+			Expect(k8sClient.Status().Update(ctx, &service)).Should(Succeed())
+
+			Eventually(func() v1.ConditionStatus {
+				if err := k8sClient.Get(ctx, readyKey, &f); err != nil {
+					return ""
+				}
+				if condition := findCondition(&f, v1beta1.Ready); condition != nil {
+					return condition.Status
+				}
+				return ""
+			}, timeout, interval).Should(Equal(v1.ConditionTrue))
+			Expect(findCondition(&f, v1beta1.DeploymentAvailable).Status).To(Equal(v1.ConditionTrue))
+			Expect(findCondition(&f, v1beta1.ServiceReady).Status).To(Equal(v1.ConditionTrue))
+
+			// The address of the load balancer can be reported by the reconciliation following the readiness
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, readyKey, &f); err != nil {
+					return ""
+				}
+				return f.Status.URL
+			}, timeout, interval).Should(Equal("http://203.0.113.10"))
+		})
+	})
+
+	When("the load balancer of a Static resource has hostnames", func() {
+
+		var (
+			hostnameKey = types.NamespacedName{
+				Name:      "my-hostname-static",
+				Namespace: "my-ns",
+			}
+
+			hostnameServiceKey = types.NamespacedName{
+				Name:      "my-hostname-static-service",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      hostnameKey.Name,
+					Namespace: hostnameKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					DiskSize:    *resource.NewQuantity(1024*1024, resource.BinarySI),
+					Source:      v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+					MinReplicas: 1,
+					MaxReplicas: 2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("all the addresses and the URL are reported in the status", func() {
+			var service v1.Service
+			Eventually(func() error {
+				return k8sClient.Get(ctx, hostnameServiceKey, &service)
+			}, timeout, interval).Should(BeNil())
+			service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{
+				{
+					Hostname: "a1.elb.amazonaws.com",
+				},
+				{
+					Hostname: "a2.elb.amazonaws.com",
+				},
+			}
+			Expect(k8sClient.Status().Update(ctx, &service)).Should(Succeed())
+
+			var f v1beta1.Static
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, hostnameKey, &f); err != nil {
+					return ""
+				}
+				return f.Status.URL
+			}, timeout, interval).Should(Equal("http://a1.elb.amazonaws.com"))
+			Expect(f.Status.ExternalIP).To(Equal("a1.elb.amazonaws.com"))
+			Expect(f.Status.LoadBalancerAddresses).To(Equal([]string{"a1.elb.amazonaws.com", "a2.elb.amazonaws.com"}))
+		})
+	})
+
+	When("a Static resource is stored without the defaults of the webhook", func() {
+
+		var (
+			defaultsKey = types.NamespacedName{
+				Name:      "my-defaults-static",
+				Namespace: "my-ns",
+			}
+
+			defaultsDeploymentKey = types.NamespacedName{
+				Name:      "my-defaults-static-deployment",
+				Namespace: "my-ns",
+			}
+
+			defaultsHPAKey = types.NamespacedName{
+				Name:      "my-defaults-static-hpa",
+				Namespace: "my-ns",
+			}
+
+			created v1beta1.Static
+		)
+
+		BeforeEach(func() {
+			created = v1beta1.Static{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      defaultsKey.Name,
+					Namespace: defaultsKey.Namespace,
+				},
+				Spec: v1beta1.StaticSpec{
+					Source: v1beta1.Source{Type: v1beta1.SourceTypeGCS, URL: "gs://my-bucket/docs"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &created)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			k8sClient.Delete(ctx, &created)
+		})
+
+		Specify("the defaults of the configuration are used", func() {
+			var deployment appsv1.Deployment
+			Eventually(func() error {
+				return k8sClient.Get(ctx, defaultsDeploymentKey, &deployment)
+			}, timeout, interval).Should(BeNil())
+			Expect(deployment.Spec.Template.Spec.Volumes[0].EmptyDir.SizeLimit.String()).To(Equal("20Mi"))
+
+			var hpa autoscalingv1.HorizontalPodAutoscaler
+			Eventually(func() error {
+				return k8sClient.Get(ctx, defaultsHPAKey, &hpa)
+			}, timeout, interval).Should(BeNil())
+			Expect(*hpa.Spec.MinReplicas).To(Equal(int32(1)))
+			Expect(hpa.Spec.MaxReplicas).To(Equal(int32(4)))
+		})
+	})
+})
+
+// receiveEvent returns the first event recorded with the reason, skipping the events recorded with other reasons
+func receiveEvent(reason string, timeout time.Duration) string {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case event := <-eventRecorder.Events:
+			if strings.Contains(event, " "+reason+" ") {
+				return event
+			}
+		case <-deadline:
+			return ""
+		}
+	}
+}
+
+// drainEvents discards the recorded events, so that the recorder never blocks the reconciler
+func drainEvents() {
+	for {
+		select {
+		case <-eventRecorder.Events:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// sourceHashAnnotation is set on the Job populating the shared volume with the revision of the volume it populates
+const sourceHashAnnotation = "website.example.com/source-hash"
+
+// sharedVolumeName is the name of the volume populated by the Job
+const sharedVolumeName = "static-files"
+
+// jobBackoffLimit is the number of retries of the Job populating the shared volume before it is marked as failed
+const jobBackoffLimit = 3
+
+// jobActiveDeadline is the duration after which the Job populating the shared volume is marked as failed
+const jobActiveDeadline = 30 * time.Minute
+
+// jobName returns the name of the Job populating the shared volume of the Static
+func jobName(static *websitev1beta1.Static) string {
+	return static.Name + "-job"
+}
+
+// sharedClaimName returns the name of the PersistentVolumeClaim served by the instances of the Static,
+// empty until the operator has populated a first volume
+func sharedClaimName(static *websitev1beta1.Static) string {
+	if static.Spec.SharedVolume.ClaimName != "" {
+		return static.Spec.SharedVolume.ClaimName
+	}
+	return static.Status.SharedClaimName
+}
+
+// sharedVolumeRevision returns a fingerprint of the source and of the volume populated by the operator.
+// A new volume is populated when it changes, the previous one being served in the meantime
+func (r *StaticReconciler) sharedVolumeRevision(static *websitev1beta1.Static) (string, error) {
+	fetcher, err := r.createFetcher(static, sharedVolumeName)
+	if err != nil {
+		return "", err
+	}
+	hash, err := json.Marshal([]interface{}{
+		fetcher,
+		createCredentialsVolumes(static),
+		static.Status.ChangedContentHash,
+		static.Spec.DiskSize,
+		static.Spec.SharedVolume.StorageClassName,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(hash))[:10], nil
+}
+
+// applySharedVolume creates and populates the shared volume of the Static, if any,
+// and returns true when a populated volume can be served
+func (r *StaticReconciler) applySharedVolume(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) (bool, error) {
+
+	if static.Spec.SharedVolume == nil {
+		if err := r.deletePopulatedVolume(ctx, log, static); err != nil {
+			return false, err
+		}
+		if removeCondition(static, websitev1beta1.SharedVolumePopulated) {
+			return true, r.Status().Update(ctx, static)
+		}
+		return true, nil
+	}
+
+	if static.Spec.SharedVolume.ClaimName != "" {
+		// The volume is populated by the user
+		if err := r.deletePopulatedVolume(ctx, log, static); err != nil {
+			return false, err
+		}
+		return true, r.applySharedVolumeCondition(ctx, static, corev1.ConditionTrue, "Provided", "The volume is provided already populated")
+	}
+
+	// The volume populated previously, if any, is served until the new one is populated
+	served := static.Status.SharedClaimName != ""
+
+	revision, err := r.sharedVolumeRevision(static)
+	if err != nil {
+		// The spec needs to be fixed, there is no need to requeue
+		log.Error(err, "unable to build job for static")
+		r.Recorder.Eventf(static, corev1.EventTypeWarning, "invalid-source", "The job cannot be built: %s", err)
+		return served, nil
+	}
+	claimName := static.Name + "-pvc-" + revision
+
+	if err := r.applyPVC(ctx, log, static, claimName); err != nil {
+		return false, err
+	}
+
+	job, err := r.applyJob(ctx, log, static, claimName, revision)
+	if err != nil || job == nil {
+		return served, err
+	}
+
+	if job.Status.Succeeded > 0 {
+		if static.Status.SharedClaimName != claimName {
+			log.Info(fmt.Sprintf("Volume populated => Serve volume %s", claimName))
+			static.Status.SharedClaimName = claimName
+			if err := r.Status().Update(ctx, static); err != nil {
+				return false, err
+			}
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "switch-volume", "The shared volume '%s.%s' is now served", static.Namespace, claimName)
+		}
+		if err := r.deletePreviousPVCs(ctx, log, static); err != nil {
+			return false, err
+		}
+		return true, r.applySharedVolumeCondition(ctx, static, corev1.ConditionTrue, "Populated", "The volume has been populated from the source")
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return served, r.applySharedVolumeCondition(ctx, static, corev1.ConditionFalse, "PopulateFailed", condition.Message)
+		}
+	}
+	if served {
+		return true, r.applySharedVolumeCondition(ctx, static, corev1.ConditionTrue, "Repopulating", "The previous volume is served while a new volume is populated from the source")
+	}
+	return false, r.applySharedVolumeCondition(ctx, static, corev1.ConditionFalse, "Populating", "The volume is being populated from the source")
+}
+
+func (r *StaticReconciler) applySharedVolumeCondition(ctx context.Context, static *websitev1beta1.Static, status corev1.ConditionStatus, reason, message string) error {
+	if !setCondition(static, websitev1beta1.SharedVolumePopulated, status, reason, message) {
+		return nil
+	}
+
+	if err := r.Status().Update(ctx, static); err != nil {
+		return err
+	}
+
+	switch reason {
+	case "Populated", "Provided":
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "populate-volume", "The shared volume '%s.%s' is populated", static.Namespace, sharedClaimName(static))
+	case "PopulateFailed":
+		r.Recorder.Eventf(static, corev1.EventTypeWarning, "populate-volume-failed", "The shared volume of the static cannot be populated: %s", message)
+	}
+	return nil
+}
+
+func (r *StaticReconciler) applyPVC(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, claimName string) error {
+
+	// Create in memory the PVC that is expected to exist into cluster
+	expected := r.createPVC(static, claimName)
+
+	// Get the existing PVC from cluster, if any.
+	// The spec of a PVC is immutable, it is not checked
+	found := new(corev1.PersistentVolumeClaim)
+	err := r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
+	if err == nil {
+		return nil
+	}
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		// Error trying to get
+		return err
+	}
+
+	log.Info("PVC not found => Create PVC")
+
+	// Set static as parent of PVC
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create PVC for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-pvc", "The persistent volume claim '%s.%s' has been created", expected.Namespace, expected.Name)
+
+	return nil
+}
+
+// deletePopulatedVolume deletes the Job and the volumes populated by the operator,
+// when the Static does not serve a volume populated by the operator anymore
+func (r *StaticReconciler) deletePopulatedVolume(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+	job := new(batchv1.Job)
+	err := r.Get(ctx, types.NamespacedName{Name: jobName(static), Namespace: static.Namespace}, job)
+	if err == nil && metav1.IsControlledBy(job, static) {
+
+		log.Info("Job found but no volume to populate => Delete job")
+
+		err = r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-job", "The job '%s.%s' has been deleted, the shared volume being removed", job.Namespace, job.Name)
+	} else if err = client.IgnoreNotFound(err); err != nil {
+		return err
+	}
+
+	if static.Status.SharedClaimName != "" {
+		static.Status.SharedClaimName = ""
+		if err := r.Status().Update(ctx, static); err != nil {
+			return err
+		}
+	}
+	return r.deletePreviousPVCs(ctx, log, static)
+}
+
+// deletePreviousPVCs deletes the volumes populated previously for the Static, but the one served, if any.
+// A volume still mounted by pods is only removed by Kubernetes when they are stopped
+func (r *StaticReconciler) deletePreviousPVCs(ctx context.Context, log logr.Logger, static *websitev1beta1.Static) error {
+	pvcs := new(corev1.PersistentVolumeClaimList)
+	err := r.List(ctx, pvcs, client.InNamespace(static.Namespace), client.MatchingLabels(pvcLabels(static)))
+	if err != nil {
+		return err
+	}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if pvc.Name == static.Status.SharedClaimName || pvc.DeletionTimestamp != nil || !metav1.IsControlledBy(pvc, static) {
+			continue
+		}
+
+		log.Info(fmt.Sprintf("Previous PVC %s found => Delete PVC", pvc.Name))
+
+		err = r.Delete(ctx, pvc)
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-pvc", "The persistent volume claim '%s.%s' has been deleted, it is no longer served", pvc.Namespace, pvc.Name)
+	}
+	return nil
+}
+
+// pvcLabels returns the labels of the PersistentVolumeClaims populated for the Static
+func pvcLabels(static *websitev1beta1.Static) map[string]string {
+	return map[string]string{
+		"app": static.Name + "-pvc",
+	}
+}
+
+func (r *StaticReconciler) createPVC(static *websitev1beta1.Static, claimName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:      claimName,
+			Namespace: static.Namespace,
+			Labels:    pvcLabels(static),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			// Written once by the Job, then read by all the instances
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+				corev1.ReadOnlyMany,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: static.Spec.DiskSize,
+				},
+			},
+			StorageClassName: static.Spec.SharedVolume.StorageClassName,
+		},
+	}
+}
+
+// applyJob returns the Job populating the shared volume, or nil if it is being (re)created
+func (r *StaticReconciler) applyJob(ctx context.Context, log logr.Logger, static *websitev1beta1.Static, claimName, revision string) (*batchv1.Job, error) {
+
+	// Create in memory the Job that is expected to exist into cluster
+	expected, err := r.createJob(static, claimName, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the existing Job from cluster, if any
+	found := new(batchv1.Job)
+	err = r.Get(ctx, types.NamespacedName{Name: expected.ObjectMeta.Name, Namespace: expected.ObjectMeta.Namespace}, found)
+	if err == nil {
+		// Job exists in cluster
+
+		if found.Annotations[sourceHashAnnotation] != expected.Annotations[sourceHashAnnotation] {
+
+			// The template of a Job is immutable, the Job is deleted and will be created again
+			log.Info("Job found but with a different source => Delete job")
+
+			err = r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil {
+				return nil, client.IgnoreNotFound(err)
+			}
+
+			r.Recorder.Eventf(static, corev1.EventTypeNormal, "delete-job", "The job '%s.%s' has been deleted due to a change of source", found.Namespace, found.Name)
+			return nil, nil
+		}
+		return found, nil
+	}
+
+	err = client.IgnoreNotFound(err)
+	if err != nil {
+		// Error trying to get
+		return nil, err
+	}
+
+	log.Info("Job not found => Create job")
+
+	// Set static as parent of job
+	controllerutil.SetControllerReference(static, expected, r.Scheme)
+
+	if err = r.Create(ctx, expected); err != nil {
+		log.Error(err, "unable to create job for static")
+	}
+
+	r.Recorder.Eventf(static, corev1.EventTypeNormal, "create-job", "The job '%s.%s' has been created", expected.Namespace, expected.Name)
+
+	return nil, nil
+}
+
+// createJob returns the Job populating a new volume, the revision of the volume is set as annotation
+func (r *StaticReconciler) createJob(static *websitev1beta1.Static, claimName, revision string) (*batchv1.Job, error) {
+	fetcher, err := r.createFetcher(static, sharedVolumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	backoffLimit := int32(jobBackoffLimit)
+	activeDeadlineSeconds := int64(jobActiveDeadline / time.Second)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+
+			Name:      jobName(static),
+			Namespace: static.Namespace,
+			Annotations: map[string]string{
+				sourceHashAnnotation: revision,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			// The failures of the Job, e.g. a checksum mismatch, are reported in the conditions of the Static
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &activeDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyOnFailure,
+					ServiceAccountName: serviceAccountName(static),
+					Volumes: append([]corev1.Volume{
+						{
+							Name: sharedVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: claimName,
+								},
+							},
+						},
+					}, createCredentialsVolumes(static)...),
+					Containers: []corev1.Container{
+						*fetcher,
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -22,6 +22,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
@@ -31,7 +32,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 	// +kubebuilder:scaffold:imports
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -58,7 +59,10 @@ var _ = BeforeSuite(func(done Done) {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("testdata", "crd"),
+		},
 	}
 
 	var err error
@@ -66,7 +70,7 @@ var _ = BeforeSuite(func(done Done) {
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cfg).ToNot(BeNil())
 
-	err = websitev1alpha1.AddToScheme(scheme.Scheme)
+	err = websitev1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	// Add controller
@@ -77,15 +81,26 @@ var _ = BeforeSuite(func(done Done) {
 
 	eventRecorder = record.NewFakeRecorder(100)
 	err = (&StaticReconciler{
-		Client: k8sManager.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Static"),
-		Scheme: k8sManager.GetScheme(),
+		Client:     k8sManager.GetClient(),
+		KubeClient: kubernetes.NewForConfigOrDie(cfg),
+		Log:        ctrl.Log.WithName("controllers").WithName("Static"),
+		Scheme:     k8sManager.GetScheme(),
 		Config: &StaticConfiguration{
-			MemoryRequestMi: 32,
-			MemoryLimitMi:   128,
-			CpuRequestMilli: 100,
-			CpuLimitMilli:   500,
-			CpuUtilization:  400,
+			MemoryRequestMi:  32,
+			MemoryLimitMi:    128,
+			CpuRequestMilli:  100,
+			CpuLimitMilli:    500,
+			CpuUtilization:   400,
+			GcsFetcherImage:  "gcr.io/cloud-builders/gcloud",
+			S3FetcherImage:   "amazon/aws-cli",
+			GitFetcherImage:  "alpine/git",
+			OciFetcherImage:  "gcr.io/go-containerregistry/crane:debug",
+			HttpFetcherImage: "alpine",
+			TlsIssuerName:    "letsencrypt",
+			TlsIssuerKind:    "ClusterIssuer",
+
+			DefaultMaxReplicas: 4,
+			DefaultDiskSize:    "20Mi",
 		},
 		Recorder: eventRecorder,
 	}).SetupWithManager(k8sManager)
@@ -21,13 +21,16 @@ import (
 	"os"
 
 	"github.com/kelseyhightower/envconfig"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	websitev1alpha1 "example.com/website/v1alpha1/api/v1alpha1"
+	websitev1beta1 "example.com/website/v1alpha1/api/v1beta1"
 	"example.com/website/v1alpha1/controllers"
 	// +kubebuilder:scaffold:imports
 )
@@ -41,6 +44,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = websitev1alpha1.AddToScheme(scheme)
+	_ = websitev1beta1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -55,34 +59,60 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	var staticConfig controllers.StaticConfiguration
+	if err := envconfig.Process("STATIC", &staticConfig); err != nil {
+		setupLog.Error(err, "unable to get configuration")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "cbceafd7.example.com",
+		// The Secrets, among others, are cached for all the namespaces unless the operator watches a single one
+		Namespace: staticConfig.WatchNamespace,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	var staticConfig controllers.StaticConfiguration
-	if err = envconfig.Process("STATIC", &staticConfig); err != nil {
-		setupLog.Error(err, "unable to get configuration")
+	// The defaults are also applied by the controller, to the Statics stored without the webhooks
+	diskSize, err := resource.ParseQuantity(staticConfig.DefaultDiskSize)
+	if err != nil {
+		setupLog.Error(err, "invalid default disk size")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.StaticReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("Static"),
-		Scheme:   mgr.GetScheme(),
-		Config:   &staticConfig,
-		Recorder: mgr.GetEventRecorderFor("Static"),
+		Client:     mgr.GetClient(),
+		KubeClient: kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		Log:        ctrl.Log.WithName("controllers").WithName("Static"),
+		Scheme:     mgr.GetScheme(),
+		Config:     &staticConfig,
+		Recorder:   mgr.GetEventRecorderFor("Static"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Static")
 		os.Exit(1)
 	}
+	// The webhooks are served with the certificate issued by cert-manager, they can be disabled to run the manager locally
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		defaults := websitev1beta1.StaticDefaults{
+			MaxReplicas: staticConfig.DefaultMaxReplicas,
+			DiskSize:    diskSize,
+		}
+		// The webhook of v1beta1, the hub version, also serves the conversion between the versions
+		if err = (&websitev1beta1.Static{}).SetupWebhookWithManager(mgr, defaults); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Static", "version", "v1beta1")
+			os.Exit(1)
+		}
+		if err = (&websitev1alpha1.Static{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Static", "version", "v1alpha1")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")